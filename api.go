@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/history"
+)
+
+// apiDateFormat is the format of dates in API parameters and responses
+const apiDateFormat = "2006-01-02"
+
+// apiResponse is the envelope of every JSON API response
+type apiResponse struct {
+	Status string `json:"status"` // "success" or "error"
+	Data   any    `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// apiRate is one observation returned by /api/v1/rates
+type apiRate struct {
+	Source          string    `json:"source"`
+	Maturity        string    `json:"maturity"`
+	Frequency       string    `json:"frequency"`
+	PublicationDate string    `json:"publication_date"`
+	Rate            float64   `json:"rate"`
+	ObservedAt      time.Time `json:"observed_at"`
+}
+
+// apiLatestRate is the current rate of one series returned by
+// /api/v1/rates/latest
+type apiLatestRate struct {
+	Source          string    `json:"source"`
+	Maturity        string    `json:"maturity"`
+	Frequency       string    `json:"frequency"`
+	Rate            float64   `json:"rate"`
+	PublicationDate string    `json:"publication_date"`
+	FetchedAt       time.Time `json:"fetched_at"`  // When the rate was last fetched successfully
+	AgeSeconds      int64     `json:"age_seconds"` // Whole seconds since FetchedAt
+	Stale           bool      `json:"stale"`       // Not refreshed within metrics.series_ttl
+	Restored        bool      `json:"restored"`    // Loaded from the state file and not fetched since
+}
+
+func writeAPI(w http.ResponseWriter, status int, resp apiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.WithError(err).Debug("Failed to write API response")
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPI(w, status, apiResponse{Status: "error", Error: err.Error()})
+}
+
+// parseHistoryQuery reads the maturity, source, from and to parameters of a
+// history request
+func parseHistoryQuery(r *http.Request) (history.Query, error) {
+	params := r.URL.Query()
+	q := history.Query{
+		Maturity: params.Get("maturity"),
+		Source:   params.Get("source"),
+	}
+
+	for _, p := range []struct {
+		name string
+		date *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		value := params.Get(p.name)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse(apiDateFormat, value)
+		if err != nil {
+			return q, fmt.Errorf("invalid %s date %q, want YYYY-MM-DD", p.name, value)
+		}
+		*p.date = date
+	}
+
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return q, fmt.Errorf("to date %s is before from date %s",
+			q.To.Format(apiDateFormat), q.From.Format(apiDateFormat))
+	}
+
+	return q, nil
+}
+
+// ratesHandler serves the rate history as JSON, filtered by the optional
+// maturity, source, from and to (YYYY-MM-DD, inclusive) parameters
+func ratesHandler(e *EuriborExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		q, err := parseHistoryQuery(r)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		rates := []apiRate{}
+		for _, o := range e.history.Query(q) {
+			rates = append(rates, apiRate{
+				Source:          o.Source,
+				Maturity:        o.Maturity,
+				Frequency:       frequencyLabel(o.Frequency),
+				PublicationDate: o.PublicationDate.Format(apiDateFormat),
+				Rate:            o.Rate,
+				ObservedAt:      o.ObservedAt.UTC(),
+			})
+		}
+
+		writeAPI(w, http.StatusOK, apiResponse{Status: "success", Data: rates})
+	}
+}
+
+// latestRates returns the current rate of every series that has one, ordered
+// by maturity and source, and when that answer last changed: a successful
+// fetch, a series turning stale or a series being removed, whichever came
+// last. The change time never goes back, even when the newest series is removed.
+func (e *EuriborExporter) latestRates(now time.Time) ([]apiLatestRate, time.Time) {
+	ttl := e.current().seriesTTL
+
+	e.snapshots.mu.RLock()
+	rates := []apiLatestRate{}
+	var staleSince time.Time
+	for key, snap := range e.snapshots.series {
+		if !snap.hasRate {
+			continue
+		}
+
+		stale := snap.stale(now, ttl)
+		if since := snap.lastSuccess.Add(ttl); stale && since.After(staleSince) {
+			staleSince = since
+		}
+
+		rates = append(rates, apiLatestRate{
+			Source:          key.source,
+			Maturity:        key.maturity,
+			Frequency:       frequencyLabel(snap.rate.Frequency),
+			Rate:            snap.rate.Rate,
+			PublicationDate: snap.rate.PublicationDate.Format(apiDateFormat),
+			FetchedAt:       snap.lastSuccess.UTC(),
+			AgeSeconds:      int64(now.Sub(snap.lastSuccess) / time.Second),
+			Stale:           stale,
+			Restored:        snap.restored,
+		})
+	}
+	e.snapshots.mu.RUnlock()
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Maturity != rates[j].Maturity {
+			return rates[i].Maturity < rates[j].Maturity
+		}
+		return rates[i].Source < rates[j].Source
+	})
+
+	return rates, e.snapshots.markChanged(staleSince)
+}
+
+// latestHandler serves the current rate of every series as JSON. Responses
+// carry a weak ETag and Last-Modified, so pollers can revalidate with
+// If-None-Match or If-Modified-Since and get 304 Not Modified until a rate
+// changes. The ETag leaves out the ages, which change every second.
+func latestHandler(e *EuriborExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		rates, modified := e.latestRates(time.Now())
+		body, err := json.Marshal(apiResponse{Status: "success", Data: rates})
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		unaged := make([]apiLatestRate, len(rates))
+		for i, rate := range rates {
+			rate.AgeSeconds = 0
+			unaged[i] = rate
+		}
+		tagged, err := json.Marshal(unaged)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		hash := fnv.New64a()
+		hash.Write(tagged)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%016x"`, hash.Sum64()))
+		http.ServeContent(w, r, "", modified, bytes.NewReader(append(body, '\n')))
+	}
+}
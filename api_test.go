@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRatesHandler(t *testing.T) {
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081, "12M": 2.264},
+	}
+	exporter, _ := newTestExporter(t, src, time.Hour, false)
+
+	// Polling the same publication again adds nothing
+	poll(exporter)
+	poll(exporter)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantRates  int
+	}{
+		{"all", "", http.StatusOK, 2},
+		{"maturity", "?maturity=3M", http.StatusOK, 1},
+		{"source", "?source=other", http.StatusOK, 0},
+		{"date range", "?maturity=3M&from=2025-12-15&to=2025-12-15", http.StatusOK, 1},
+		{"before range", "?to=2025-12-14", http.StatusOK, 0},
+		{"bad date", "?from=15.12.2025", http.StatusBadRequest, 0},
+		{"reversed range", "?from=2025-12-16&to=2025-12-15", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ratesHandler(exporter)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/rates"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var resp struct {
+				Status string    `json:"status"`
+				Data   []apiRate `json:"data"`
+				Error  string    `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid JSON response: %v", err)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				if resp.Status != "error" || resp.Error == "" {
+					t.Errorf("response = %+v, want an error", resp)
+				}
+				return
+			}
+			if resp.Status != "success" || len(resp.Data) != tt.wantRates {
+				t.Errorf("response = %+v, want %d rates", resp, tt.wantRates)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	ratesHandler(exporter)(rec, httptest.NewRequest(http.MethodGet, "/api/v1/rates?maturity=3M", nil))
+	want := `{"status":"success","data":[{"source":"fake","maturity":"3M","frequency":"daily","publication_date":"2025-12-15","rate":2.081,"observed_at":`
+	if got := rec.Body.String(); len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("body = %s, want prefix %s", got, want)
+	}
+}
+
+func TestLatestHandler(t *testing.T) {
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081},
+	}
+	exporter, _ := newTestExporter(t, src, time.Hour, false)
+	poll(exporter)
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rates/latest", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		latestHandler(exporter)(rec, req)
+		return rec
+	}
+
+	first := get("", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", first.Code, http.StatusOK)
+	}
+
+	var resp struct {
+		Status string          `json:"status"`
+		Data   []apiLatestRate `json:"data"`
+	}
+	if err := json.Unmarshal(first.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if len(resp.Data) != 1 {
+		t.Fatalf("response = %+v, want 1 rate", resp)
+	}
+	if got := resp.Data[0]; got.Source != "fake" || got.Maturity != "3M" || got.Rate != 2.081 ||
+		got.PublicationDate != "2025-12-15" || got.Stale || got.FetchedAt.IsZero() || got.AgeSeconds < 0 {
+		t.Errorf("rate = %+v, want fresh 3M rate 2.081 published 2025-12-15", got)
+	}
+
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("ETag = %q, Last-Modified = %q, want both set", etag, lastModified)
+	}
+
+	// Unchanged rates are not sent again
+	if rec := get("If-None-Match", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec := get("If-Modified-Since", lastModified); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	// A series turning stale is a change
+	key := seriesKey{source: "fake", maturity: "3M"}
+	exporter.snapshots.mu.Lock()
+	snap := exporter.snapshots.series[key]
+	snap.lastSuccess = time.Now().Add(-2 * time.Hour)
+	exporter.snapshots.series[key] = snap
+	exporter.snapshots.mu.Unlock()
+
+	rec := get("If-None-Match", etag)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status after turning stale = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if len(resp.Data) != 1 || !resp.Data[0].Stale {
+		t.Errorf("response = %+v, want the stale 3M rate", resp)
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("ETag unchanged after the series turned stale")
+	}
+}
+
+func TestLatestRatesModifiedNeverGoesBack(t *testing.T) {
+	log.SetLevel(logrus.PanicLevel)
+
+	older := &fakeSource{name: "older"}
+	newer := &fakeSource{name: "newer"}
+	both := source.NewRegistry()
+	both.MustRegister(older)
+	both.MustRegister(newer)
+
+	cfg := exporterConfig{
+		sources:     both,
+		maturities:  []string{"3M"},
+		concurrency: 1,
+		seriesTTL:   time.Hour,
+	}
+	exporter := NewEuriborExporter(cfg)
+
+	now := time.Now()
+	rate := &source.Rate{Rate: 2.081, PublicationDate: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)}
+	exporter.snapshots.recordSuccess(seriesKey{source: "older", maturity: "3M"}, rate, now.Add(-20*time.Minute), now.Add(-20*time.Minute))
+	exporter.snapshots.recordSuccess(seriesKey{source: "newer", maturity: "3M"}, rate, now.Add(-10*time.Minute), now.Add(-10*time.Minute))
+
+	rates, modified := exporter.latestRates(now)
+	if len(rates) != 2 || !modified.Equal(now.Add(-10*time.Minute)) {
+		t.Fatalf("latestRates() = %d rates modified %s, want 2 rates modified at the newer fetch", len(rates), modified)
+	}
+	if age := rates[0].AgeSeconds; age != 600 {
+		t.Errorf("AgeSeconds = %d, want 600", age)
+	}
+
+	// Removing the newest series is a change, not a step back
+	onlyOlder := source.NewRegistry()
+	onlyOlder.MustRegister(older)
+	cfg.sources = onlyOlder
+	exporter.Reload(cfg)
+
+	rates, after := exporter.latestRates(now)
+	if len(rates) != 1 || !after.After(modified) {
+		t.Errorf("latestRates() after removal = %d rates modified %s, want 1 rate modified after %s", len(rates), after, modified)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/ecb"
+	"github.com/GoGstickGo/euribor-exporter/retry"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/sirupsen/logrus"
+)
+
+// backfillSeries is one metric series written by the backfill command
+type backfillSeries struct {
+	labels       string
+	observations []ecb.Observation
+}
+
+// backfillFamily groups the series of a single metric name, as OpenMetrics
+// requires all samples of a family to be contiguous
+type backfillFamily struct {
+	name   string
+	help   string
+	series []backfillSeries
+}
+
+// runBackfill implements the "backfill" subcommand: it loads the Euribor history
+// from the ECB SDMX API and writes it as OpenMetrics text suitable for
+// `promtool tsdb create-blocks-from openmetrics`
+func runBackfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	from := fs.String("from", "1999-01-01", "Start date (YYYY-MM-DD)")
+	to := fs.String("to", time.Now().UTC().Format("2006-01-02"), "End date (YYYY-MM-DD)")
+	maturityList := fs.String("maturities", strings.Join(ecb.SupportedMaturities(), ","), "Comma-separated maturities to backfill")
+	daily := fs.Bool("daily", true, "Also backfill daily series where the ECB publishes them")
+	output := fs.String("output", "-", "Output file (- for stdout)")
+	apiURL := fs.String("ecb-api-url", ecb.DefaultBaseURL, "Base URL of the ECB SDMX data API")
+	timeout := fs.Duration("timeout", 60*time.Second, "Timeout for each ECB request")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s backfill [flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Writes historical Euribor rates from the ECB as OpenMetrics text.\n")
+		fmt.Fprintf(fs.Output(), "Load the result with: promtool tsdb create-blocks-from openmetrics <file> <data dir>\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	fromDate, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("invalid --from date: %w", err)
+	}
+	toDate, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		return fmt.Errorf("invalid --to date: %w", err)
+	}
+	if toDate.Before(fromDate) {
+		return fmt.Errorf("--to (%s) is before --from (%s)", *to, *from)
+	}
+
+	var maturityNames []string
+	for _, m := range strings.Split(*maturityList, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			maturityNames = append(maturityNames, m)
+		}
+	}
+
+	client := ecb.NewClient(*apiURL, &http.Client{
+		Timeout:   *timeout,
+		Transport: retry.NewTransport(retry.DefaultPolicy(), nil),
+	}, log)
+
+	// Abort in-flight requests on Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var series []backfillResult
+	for _, maturity := range maturityNames {
+		observations, err := fetchBackfillSeries(ctx, client, ecb.Monthly, maturity, fromDate, toDate)
+		if err != nil {
+			return fmt.Errorf("failed to backfill monthly %s: %w", maturity, err)
+		}
+		series = append(series, backfillResult{maturity, source.Monthly, observations})
+
+		if !*daily {
+			continue
+		}
+
+		observations, err = fetchBackfillSeries(ctx, client, ecb.Daily, maturity, fromDate, toDate)
+		if errors.Is(err, ecb.ErrNoData) {
+			log.WithField("maturity", maturity).Warn("No daily ECB series available, skipping")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to backfill daily %s: %w", maturity, err)
+		}
+		series = append(series, backfillResult{maturity, source.Daily, observations})
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return writeOpenMetrics(w, backfillFamilies(series))
+}
+
+// backfillResult is the history of one ECB series
+type backfillResult struct {
+	maturity     string
+	frequency    string // source.Daily or source.Monthly
+	observations []ecb.Observation
+}
+
+// backfillFamilies names the fetched series like the live ECB series, so
+// backfilled samples continue euribor_rate_percent{source="ecb",maturity,frequency}
+func backfillFamilies(results []backfillResult) []backfillFamily {
+	rates := backfillFamily{
+		name: namespace + "_rate_percent",
+		help: "Euribor rate in percent",
+	}
+
+	for _, r := range results {
+		rates.series = append(rates.series, backfillSeries{
+			labels:       fmt.Sprintf(`source="%s",maturity="%s",frequency="%s"`, sourceECB, r.maturity, r.frequency),
+			observations: r.observations,
+		})
+	}
+
+	return []backfillFamily{rates}
+}
+
+// fetchBackfillSeries fetches one series and logs how many observations it returned
+func fetchBackfillSeries(ctx context.Context, client *ecb.Client, freq ecb.Frequency, maturity string, from, to time.Time) ([]ecb.Observation, error) {
+	observations, err := client.FetchSeries(ctx, freq, maturity, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(logrus.Fields{
+		"maturity":     maturity,
+		"frequency":    string(freq),
+		"observations": len(observations),
+		"first":        observations[0].Period,
+		"last":         observations[len(observations)-1].Period,
+	}).Info("Fetched ECB history")
+
+	return observations, nil
+}
+
+// writeOpenMetrics writes families as OpenMetrics text with per-sample timestamps
+func writeOpenMetrics(w io.Writer, families []backfillFamily) error {
+	bw := bufio.NewWriter(w)
+
+	for _, family := range families {
+		if len(family.series) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", family.name)
+
+		for _, s := range family.series {
+			for _, obs := range s.observations {
+				fmt.Fprintf(bw, "%s{%s} %g %d\n", family.name, s.labels, obs.Value, obs.Date.Unix())
+			}
+		}
+	}
+
+	fmt.Fprint(bw, "# EOF\n")
+
+	return bw.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/ecb"
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	results := []backfillResult{
+		{"3M", source.Monthly, []ecb.Observation{
+			{Value: 2.104, Period: "2025-10", Date: time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)},
+			{Value: 2.076, Period: "2025-11", Date: time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC)},
+		}},
+		{"3M", source.Daily, []ecb.Observation{
+			{Value: 2.081, Period: "2025-12-15", Date: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)},
+		}},
+	}
+
+	want := `# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{source="ecb",maturity="3M",frequency="monthly"} 2.104 1761868800
+euribor_rate_percent{source="ecb",maturity="3M",frequency="monthly"} 2.076 1764460800
+euribor_rate_percent{source="ecb",maturity="3M",frequency="daily"} 2.081 1765756800
+# EOF
+`
+
+	var out strings.Builder
+	if err := writeOpenMetrics(&out, backfillFamilies(results)); err != nil {
+		t.Fatalf("writeOpenMetrics() unexpected error: %v", err)
+	}
+	if got := out.String(); got != want {
+		t.Errorf("writeOpenMetrics() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned for requests rejected while the circuit is open
+var ErrOpen = errors.New("circuit breaker open")
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	Closed   State = iota // Requests pass through
+	Open                  // Requests are rejected until the cool-down has passed
+	HalfOpen              // A single probe request decides whether to close again
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Policy describes when a circuit opens and when it is probed again
+type Policy struct {
+	FailureThreshold int           // Consecutive failures that open the circuit; 0 disables the breaker
+	CoolDown         time.Duration // Time the circuit stays open before a probe is let through
+}
+
+// DefaultPolicy returns the policy used when none is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		FailureThreshold: 5,
+		CoolDown:         5 * time.Minute,
+	}
+}
+
+// Breaker is a circuit breaker for a single upstream
+type Breaker struct {
+	mu       sync.Mutex
+	policy   Policy
+	state    State
+	failures int       // Consecutive failures while closed
+	openedAt time.Time // When the circuit last opened
+	probing  bool      // Whether the half-open probe is in flight
+
+	now      func() time.Time
+	onChange func(from, to State)
+}
+
+// New creates a closed breaker. onChange, if not nil, is called with the
+// breaker lock held on every state transition.
+func New(p Policy, onChange func(from, to State)) *Breaker {
+	if onChange == nil {
+		onChange = func(State, State) {}
+	}
+	return &Breaker{
+		policy:   p,
+		now:      time.Now,
+		onChange: onChange,
+	}
+}
+
+// SetPolicy replaces the policy. The current state is kept, except that
+// disabling the breaker closes it.
+func (b *Breaker) SetPolicy(p Policy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.policy = p
+	if p.FailureThreshold <= 0 {
+		b.failures = 0
+		b.probing = false
+		b.setState(Closed)
+	}
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a request may be sent. Once the cool-down has passed
+// an open circuit lets exactly one probe through and rejects the rest until
+// the probe is reported with Success, Failure or Cancel.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.policy.CoolDown {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success records a request that reached a healthy upstream
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		b.failures = 0
+	case HalfOpen:
+		b.failures = 0
+		b.probing = false
+		b.setState(Closed)
+	}
+}
+
+// Failure records a request that failed because of the upstream
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.policy.FailureThreshold <= 0 {
+		return
+	}
+
+	switch b.state {
+	case Closed:
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			b.trip()
+		}
+	case HalfOpen:
+		b.probing = false
+		b.trip()
+	}
+}
+
+// Cancel records a request that was abandoned by the caller and says nothing
+// about the upstream
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.probing = false
+	}
+}
+
+func (b *Breaker) trip() {
+	b.failures = 0
+	b.openedAt = b.now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(s State) {
+	if b.state == s {
+		return
+	}
+	from := b.state
+	b.state = s
+	b.onChange(from, s)
+}
+
+// Transport is an http.RoundTripper guarded by a Breaker. Network errors,
+// timeouts and 5xx or 429 responses count as failures; any other response
+// means the upstream is reachable, whatever the caller makes of the body.
+// Requests cancelled by the caller are not counted.
+type Transport struct {
+	Breaker *Breaker
+	Next    http.RoundTripper
+}
+
+// NewTransport wraps next with the breaker b
+func NewTransport(b *Breaker, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{
+		Breaker: b,
+		Next:    next,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Breaker.Allow(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.Next.RoundTrip(req)
+
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.Breaker.Cancel()
+	case err != nil:
+		t.Breaker.Failure()
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		t.Breaker.Failure()
+	default:
+		t.Breaker.Success()
+	}
+
+	return resp, err
+}
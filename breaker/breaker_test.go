@@ -0,0 +1,177 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker with a controllable clock and the list of
+// states it transitioned to
+func newTestBreaker(p Policy) (*Breaker, *time.Time, *[]State) {
+	var transitions []State
+	b := New(p, func(from, to State) {
+		transitions = append(transitions, to)
+	})
+
+	now := time.Date(2025, 12, 15, 10, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	return b, &now, &transitions
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b, _, _ := newTestBreaker(Policy{FailureThreshold: 3, CoolDown: time.Minute})
+
+	b.Failure()
+	b.Failure()
+	b.Success() // Resets the streak
+	b.Failure()
+	b.Failure()
+	if b.State() != Closed {
+		t.Fatalf("state = %s after 2 consecutive failures, want closed", b.State())
+	}
+
+	b.Failure()
+	if b.State() != Open {
+		t.Fatalf("state = %s after 3 consecutive failures, want open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Allow() while open = %v, want ErrOpen", err)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	b, now, transitions := newTestBreaker(Policy{FailureThreshold: 1, CoolDown: time.Minute})
+
+	b.Failure()
+	*now = now.Add(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after cool-down = %v, want nil", err)
+	}
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("second Allow() while probing = %v, want ErrOpen", err)
+	}
+
+	// A failed probe opens the circuit for another cool-down
+	b.Failure()
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Allow() after failed probe = %v, want ErrOpen", err)
+	}
+
+	*now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after second cool-down = %v, want nil", err)
+	}
+	b.Success()
+
+	want := []State{Open, HalfOpen, Open, HalfOpen, Closed}
+	if len(*transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", *transitions, want)
+	}
+	for i := range want {
+		if (*transitions)[i] != want[i] {
+			t.Errorf("transitions = %v, want %v", *transitions, want)
+			break
+		}
+	}
+}
+
+func TestBreakerCancelReleasesProbe(t *testing.T) {
+	b, now, _ := newTestBreaker(Policy{FailureThreshold: 1, CoolDown: time.Minute})
+
+	b.Failure()
+	*now = now.Add(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow() after cool-down = %v, want nil", err)
+	}
+	b.Cancel()
+
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() after cancelled probe = %v, want nil", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b, _, _ := newTestBreaker(Policy{FailureThreshold: 0})
+
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow() with disabled breaker = %v, want nil", err)
+	}
+}
+
+func TestTransportCountsUpstreamFailures(t *testing.T) {
+	var calls, status int32 = 0, http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer srv.Close()
+
+	b := New(Policy{FailureThreshold: 2, CoolDown: time.Hour}, nil)
+	client := &http.Client{Transport: NewTransport(b, nil)}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if i < 2 {
+			if err != nil {
+				t.Fatalf("request %d unexpected error: %v", i, err)
+			}
+			resp.Body.Close()
+			continue
+		}
+		if !errors.Is(err, ErrOpen) {
+			t.Errorf("request %d error = %v, want ErrOpen", i, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("server called %d times, want 2", calls)
+	}
+
+	// Client errors mean the upstream is reachable
+	b = New(Policy{FailureThreshold: 1, CoolDown: time.Hour}, nil)
+	client = &http.Client{Transport: NewTransport(b, nil)}
+	atomic.StoreInt32(&status, http.StatusNotFound)
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if b.State() != Closed {
+		t.Errorf("state after 404 = %s, want closed", b.State())
+	}
+}
+
+func TestTransportIgnoresCancelledRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	b := New(Policy{FailureThreshold: 1, CoolDown: time.Hour}, nil)
+	client := &http.Client{Transport: NewTransport(b, nil)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected error for cancelled request, got nil")
+	}
+	if b.State() != Closed {
+		t.Errorf("state after cancelled request = %s, want closed", b.State())
+	}
+}
@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	// Embedded so publication times resolve on hosts without a zoneinfo database
+	_ "time/tzdata"
+)
+
+// DefaultLocation is the time zone in which Euribor is published
+const DefaultLocation = "Europe/Brussels"
+
+// Easter returns Easter Sunday of year in the Gregorian calendar (UTC midnight)
+func Easter(year int) time.Time {
+	// Anonymous Gregorian algorithm (Meeus/Jones/Butcher)
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// IsHoliday reports whether the calendar date of t is a TARGET closing day:
+// New Year's Day, Good Friday, Easter Monday, Labour Day, Christmas Day or
+// 26 December
+func IsHoliday(t time.Time) bool {
+	year, month, day := t.Date()
+
+	switch {
+	case month == time.January && day == 1,
+		month == time.May && day == 1,
+		month == time.December && (day == 25 || day == 26):
+		return true
+	}
+
+	easter := Easter(year)
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return date.Equal(easter.AddDate(0, 0, -2)) || date.Equal(easter.AddDate(0, 0, 1))
+}
+
+// IsBusinessDay reports whether the calendar date of t is a TARGET business
+// day, that is neither a weekend nor a TARGET closing day
+func IsBusinessDay(t time.Time) bool {
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !IsHoliday(t)
+}
+
+// NextBusinessDay returns midnight of the first business day after the
+// calendar date of t, in t's location
+func NextBusinessDay(t time.Time) time.Time {
+	d := midnight(t)
+	for {
+		d = d.AddDate(0, 0, 1)
+		if IsBusinessDay(d) {
+			return d
+		}
+	}
+}
+
+// PrevBusinessDay returns midnight of the last business day before the
+// calendar date of t, in t's location
+func PrevBusinessDay(t time.Time) time.Time {
+	d := midnight(t)
+	for {
+		d = d.AddDate(0, 0, -1)
+		if IsBusinessDay(d) {
+			return d
+		}
+	}
+}
+
+// BusinessDaysBetween counts the business days after the calendar date of
+// from up to and including the calendar date of to, each taken in its own
+// location. It returns 0 if to is not after from.
+func BusinessDaysBetween(from, to time.Time) int {
+	year, month, day := to.Date()
+	end := time.Date(year, month, day, 0, 0, 0, 0, from.Location())
+	n := 0
+	for d := midnight(from).AddDate(0, 0, 1); !d.After(end); d = d.AddDate(0, 0, 1) {
+		if IsBusinessDay(d) {
+			n++
+		}
+	}
+	return n
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Publication is the time of day at which a new fixing is expected on every
+// business day
+type Publication struct {
+	Hour     int
+	Minute   int
+	Location *time.Location
+}
+
+// ParsePublication parses a "15:04" time of day in the named time zone
+func ParsePublication(clock, zone string) (Publication, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return Publication{}, fmt.Errorf("invalid time of day %q, want HH:MM", clock)
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return Publication{}, fmt.Errorf("invalid time zone %q: %w", zone, err)
+	}
+
+	return Publication{
+		Hour:     t.Hour(),
+		Minute:   t.Minute(),
+		Location: loc,
+	}, nil
+}
+
+// on returns the publication time on the calendar date of day
+func (p Publication) on(day time.Time) time.Time {
+	year, month, d := day.Date()
+	return time.Date(year, month, d, p.Hour, p.Minute, 0, 0, p.Location)
+}
+
+// Next returns the first expected publication strictly after t
+func (p Publication) Next(t time.Time) time.Time {
+	local := t.In(p.Location)
+	if IsBusinessDay(local) {
+		if pub := p.on(local); pub.After(t) {
+			return pub
+		}
+	}
+	return p.on(NextBusinessDay(local))
+}
+
+// Last returns the latest expected publication at or before t
+func (p Publication) Last(t time.Time) time.Time {
+	local := t.In(p.Location)
+	if IsBusinessDay(local) {
+		if pub := p.on(local); !pub.After(t) {
+			return pub
+		}
+	}
+	return p.on(PrevBusinessDay(local))
+}
@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestEaster(t *testing.T) {
+	tests := map[int]string{
+		2000: "2000-04-23",
+		2019: "2019-04-21",
+		2024: "2024-03-31",
+		2025: "2025-04-20",
+		2026: "2026-04-05",
+		2038: "2038-04-25",
+	}
+
+	for year, want := range tests {
+		if got := Easter(year); !got.Equal(date(want)) {
+			t.Errorf("Easter(%d) = %s, want %s", year, got.Format("2006-01-02"), want)
+		}
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"2025-01-01", false}, // New Year's Day
+		{"2025-01-02", true},
+		{"2025-04-17", true},  // Maundy Thursday
+		{"2025-04-18", false}, // Good Friday
+		{"2025-04-21", false}, // Easter Monday
+		{"2025-04-22", true},
+		{"2025-05-01", false}, // Labour Day
+		{"2025-05-29", true},  // Ascension is not a TARGET holiday
+		{"2025-12-13", false}, // Saturday
+		{"2025-12-14", false}, // Sunday
+		{"2025-12-15", true},
+		{"2025-12-24", true},
+		{"2025-12-25", false},
+		{"2025-12-26", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsBusinessDay(date(tt.date)); got != tt.want {
+			t.Errorf("IsBusinessDay(%s) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestNextPrevBusinessDay(t *testing.T) {
+	if got := NextBusinessDay(date("2025-04-17")); !got.Equal(date("2025-04-22")) {
+		t.Errorf("NextBusinessDay(2025-04-17) = %s, want 2025-04-22", got.Format("2006-01-02"))
+	}
+	if got := PrevBusinessDay(date("2025-04-22")); !got.Equal(date("2025-04-17")) {
+		t.Errorf("PrevBusinessDay(2025-04-22) = %s, want 2025-04-17", got.Format("2006-01-02"))
+	}
+}
+
+func TestBusinessDaysBetween(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     int
+	}{
+		{"2025-12-15", "2025-12-15", 0},
+		{"2025-12-15", "2025-12-16", 1},
+		{"2025-12-12", "2025-12-15", 1}, // Friday to Monday
+		{"2025-04-17", "2025-04-22", 1}, // Across Easter
+		{"2025-12-23", "2026-01-02", 5}, // 24, 29, 30, 31 Dec and 2 Jan
+		{"2025-12-16", "2025-12-15", 0},
+	}
+
+	for _, tt := range tests {
+		got := BusinessDaysBetween(date(tt.from), date(tt.to))
+		if got != tt.want {
+			t.Errorf("BusinessDaysBetween(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	// Dates are compared as calendar dates, whatever their locations
+	brussels, _ := time.LoadLocation(DefaultLocation)
+	if got := BusinessDaysBetween(date("2025-12-12"), time.Date(2025, 12, 15, 0, 0, 0, 0, brussels)); got != 1 {
+		t.Errorf("BusinessDaysBetween(2025-12-12 UTC, 2025-12-15 Brussels) = %d, want 1", got)
+	}
+}
+
+func TestPublication(t *testing.T) {
+	p, err := ParsePublication("11:00", DefaultLocation)
+	if err != nil {
+		t.Fatalf("ParsePublication() unexpected error: %v", err)
+	}
+	brussels := p.Location
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		next, last time.Time
+	}{
+		{
+			name: "before publication on a business day",
+			at:   time.Date(2025, 12, 15, 9, 0, 0, 0, brussels),
+			next: time.Date(2025, 12, 15, 11, 0, 0, 0, brussels),
+			last: time.Date(2025, 12, 12, 11, 0, 0, 0, brussels),
+		},
+		{
+			name: "exactly at publication",
+			at:   time.Date(2025, 12, 15, 11, 0, 0, 0, brussels),
+			next: time.Date(2025, 12, 16, 11, 0, 0, 0, brussels),
+			last: time.Date(2025, 12, 15, 11, 0, 0, 0, brussels),
+		},
+		{
+			name: "over Easter",
+			at:   time.Date(2025, 4, 18, 12, 0, 0, 0, brussels),
+			next: time.Date(2025, 4, 22, 11, 0, 0, 0, brussels),
+			last: time.Date(2025, 4, 17, 11, 0, 0, 0, brussels),
+		},
+		{
+			name: "UTC input late in the evening",
+			at:   time.Date(2025, 12, 12, 23, 30, 0, 0, time.UTC), // Saturday 00:30 in Brussels
+			next: time.Date(2025, 12, 15, 11, 0, 0, 0, brussels),
+			last: time.Date(2025, 12, 12, 11, 0, 0, 0, brussels),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Next(tt.at); !got.Equal(tt.next) {
+				t.Errorf("Next() = %s, want %s", got, tt.next)
+			}
+			if got := p.Last(tt.at); !got.Equal(tt.last) {
+				t.Errorf("Last() = %s, want %s", got, tt.last)
+			}
+		})
+	}
+}
+
+func TestParsePublicationErrors(t *testing.T) {
+	if _, err := ParsePublication("11h", DefaultLocation); err == nil {
+		t.Error("ParsePublication(11h) expected error, got nil")
+	}
+	if _, err := ParsePublication("11:00", "Mars/Olympus"); err == nil {
+		t.Error("ParsePublication with unknown zone expected error, got nil")
+	}
+}
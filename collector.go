@@ -0,0 +1,437 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/scraper"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/GoGstickGo/euribor-exporter/state"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// seriesDescs describes the metric families exported for every series. A
+// nil descriptor is not exported.
+type seriesDescs struct {
+	rate     *prometheus.Desc
+	pubDate  *prometheus.Desc
+	success  *prometheus.Desc
+	duration *prometheus.Desc
+}
+
+func (d seriesDescs) describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{d.rate, d.pubDate, d.success, d.duration} {
+		if desc != nil {
+			ch <- desc
+		}
+	}
+}
+
+// seriesFamilies pairs descriptors with the label values of one series
+type seriesFamilies struct {
+	descs      seriesDescs
+	labels     []string // Label values of success and duration
+	rateLabels []string // Label values of rate and publication date
+}
+
+func newDesc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+}
+
+var (
+	// rateDescs are the metric families exported for every source
+	rateDescs = seriesDescs{
+		rate:     newDesc("rate_percent", "Euribor rate in percent", "source", "maturity", "frequency"),
+		pubDate:  newDesc("publication_timestamp_seconds", "Publication date of the Euribor rate (Unix timestamp)", "source", "maturity", "frequency"),
+		success:  newDesc("fetch_success", "Whether the last fetch was successful (1 = success, 0 = failure)", "source", "maturity"),
+		duration: newDesc("fetch_duration_seconds", "Duration of the last fetch in seconds", "source", "maturity"),
+	}
+
+	// legacyDescs are the original names of the built-in sources, exported in
+	// legacy names mode. The ECB rate used to be euribor_rate_percent{maturity},
+	// a name the unified schema now uses with more labels, so it is not
+	// exported under its old label set.
+	legacyDescs = map[string]seriesDescs{
+		sourceDaily: {
+			rate:     newDesc("daily_rate_percent", "Daily Euribor rate in percent (scraped from euribor-rates.eu)", "maturity"),
+			pubDate:  newDesc("daily_publication_date_timestamp", "ECB publication date of the daily Euribor rate (Unix timestamp)", "maturity"),
+			success:  newDesc("daily_scrape_success", "Whether the last daily scrape was successful (1 = success, 0 = failure)", "maturity"),
+			duration: newDesc("daily_scrape_duration_seconds", "Duration of daily Euribor scrape in seconds", "maturity"),
+		},
+		sourceECB: {
+			pubDate:  newDesc("last_publication_date", "Publication date of the monthly ECB Euribor rate (Unix timestamp)", "maturity"),
+			success:  newDesc("scrape_success", "Whether the last scrape was successful (1 = success, 0 = failure)", "maturity"),
+			duration: newDesc("scrape_duration_seconds", "Duration of Euribor data scrape", "maturity"),
+		},
+	}
+
+	lastSuccessDesc = newDesc("last_success_timestamp_seconds",
+		"Time of the last successful fetch from each source (Unix timestamp)",
+		"source", "maturity")
+
+	lastAttemptDesc = newDesc("last_attempt_timestamp_seconds",
+		"Time of the last fetch attempt from each source, successful or not (Unix timestamp)",
+		"source", "maturity")
+
+	seriesRestoredDesc = newDesc("series_restored",
+		"Whether the exported rate was restored from the state file and has not been fetched since the restart (1 = restored)",
+		"source", "maturity")
+
+	seriesStaleDesc = newDesc("series_stale",
+		"Whether the series has not been fetched successfully within the series TTL and its rate is no longer exported (1 = stale)",
+		"source", "maturity")
+
+	dailyPublicationsMissedDesc = newDesc("daily_publications_missed",
+		"Number of expected TARGET publication days since the publication date of the daily Euribor rate",
+		"maturity")
+
+	sourceDivergenceDesc = newDesc("source_divergence_bp",
+		"Average of the daily fixings observed in a month minus the ECB monthly average for that month, in basis points",
+		"maturity", "period")
+
+	sourceDivergenceCoverageDesc = newDesc("source_divergence_coverage_ratio",
+		"Share of the month's TARGET business days whose daily fixing was observed for euribor_source_divergence_bp",
+		"maturity", "period")
+
+	scraperStrategyDesc = newDesc("scraper_strategy_used",
+		"Extraction strategy that found the rate table in the last daily scrape (1 = used); all 0 after a layout check failed",
+		"maturity", "strategy")
+
+	loanPaymentDesc = newDesc("loan_payment_eur",
+		"Next monthly payment of a configured loan in EUR",
+		"loan")
+
+	loanEffectiveRateDesc = newDesc("loan_effective_rate_percent",
+		"Interest rate of a configured loan until its next reset, reference rate plus margin, in percent",
+		"loan")
+
+	loanProjectedPaymentDesc = newDesc("loan_projected_payment_eur",
+		"Monthly payment of a configured loan after its next reset at the current daily reference rate, in EUR",
+		"loan")
+
+	loanProjectedRateDesc = newDesc("loan_projected_effective_rate_percent",
+		"Interest rate of a configured loan after its next reset at the current daily reference rate, in percent",
+		"loan")
+
+	loanNextResetDesc = newDesc("loan_next_reset_timestamp_seconds",
+		"Next interest reset of a configured loan (Unix timestamp)",
+		"loan")
+
+	expectedPublicationDesc = newDesc("expected_publication_timestamp",
+		"Next expected publication of a new Euribor fixing on a TARGET business day (Unix timestamp)",
+		"maturity")
+)
+
+// seriesSnapshot is the latest fetch result of one source and maturity
+type seriesSnapshot struct {
+	rate        source.Rate
+	hasRate     bool      // Whether any fetch has succeeded yet
+	success     bool      // Whether the last fetch succeeded
+	duration    float64   // Duration of the last fetch in seconds
+	lastSuccess time.Time // When the last successful fetch completed
+	lastAttempt time.Time // When the last completed fetch started
+	restored    bool      // Whether the rate was loaded from the state file and not fetched since
+	strategy    string    // Extraction strategy of the last accepted rate; cleared when the page no longer parses
+}
+
+// stale reports whether the rate was not refreshed within ttl (0 disables)
+func (s seriesSnapshot) stale(now time.Time, ttl time.Duration) bool {
+	return s.hasRate && ttl > 0 && now.Sub(s.lastSuccess) > ttl
+}
+
+// snapshotStore holds the latest fetch results that the exporter turns into
+// metrics on every collection
+type snapshotStore struct {
+	mu       sync.RWMutex
+	series   map[seriesKey]seriesSnapshot
+	expected map[string]time.Time // Next expected publication per maturity
+	changed  time.Time            // When the stored rates last changed; never goes back
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{
+		series:   make(map[seriesKey]seriesSnapshot),
+		expected: make(map[string]time.Time),
+	}
+}
+
+// recordSuccess stores the rate of a fetch that ran from started to finished
+func (s *snapshotStore) recordSuccess(key seriesKey, rate *source.Rate, started, finished time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series[key] = seriesSnapshot{
+		rate:        *rate,
+		hasRate:     true,
+		success:     true,
+		duration:    finished.Sub(started).Seconds(),
+		lastSuccess: finished,
+		lastAttempt: started,
+		strategy:    rate.Strategy,
+	}
+	s.touch(finished)
+}
+
+// recordFailure marks the fetch that ran from started to finished as failed,
+// keeping the last good rate
+func (s *snapshotStore) recordFailure(key seriesKey, started, finished time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.series[key]
+	snap.success = false
+	snap.duration = finished.Sub(started).Seconds()
+	snap.lastAttempt = started
+	s.series[key] = snap
+}
+
+// clearStrategy records that no extraction strategy matched the page
+func (s *snapshotStore) clearStrategy(key seriesKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snap, exists := s.series[key]; exists {
+		snap.strategy = ""
+		s.series[key] = snap
+	}
+}
+
+// restore stores a rate loaded from the state file
+func (s *snapshotStore) restore(key seriesKey, rate source.Rate, lastSuccess time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series[key] = seriesSnapshot{
+		rate:        rate,
+		hasRate:     true,
+		lastSuccess: lastSuccess,
+		lastAttempt: lastSuccess,
+		restored:    true,
+	}
+	s.touch(lastSuccess)
+}
+
+// persisted returns the accepted rate of every series for the state file
+func (s *snapshotStore) persisted() []state.Series {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	series := make([]state.Series, 0, len(s.series))
+	for key, snap := range s.series {
+		if !snap.hasRate {
+			continue
+		}
+		series = append(series, state.Series{
+			Source:          key.source,
+			Maturity:        key.maturity,
+			Rate:            snap.rate.Rate,
+			PublicationDate: snap.rate.PublicationDate,
+			Frequency:       snap.rate.Frequency,
+			LastSuccess:     snap.lastSuccess,
+		})
+	}
+	return series
+}
+
+// accepted returns the last accepted rate of a series
+func (s *snapshotStore) accepted(key seriesKey) (*source.Rate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap, exists := s.series[key]
+	if !exists || !snap.hasRate {
+		return nil, false
+	}
+	rate := snap.rate
+	return &rate, true
+}
+
+// peers returns the last accepted rates of maturity from every source except
+// exclude, leaving out rates not refreshed within ttl (0 keeps all)
+func (s *snapshotStore) peers(maturity, exclude string, now time.Time, ttl time.Duration) map[string]source.Rate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	peers := make(map[string]source.Rate)
+	for key, snap := range s.series {
+		if key.maturity != maturity || key.source == exclude || !snap.hasRate {
+			continue
+		}
+		if snap.stale(now, ttl) {
+			continue
+		}
+		peers[key.source] = snap.rate
+	}
+	return peers
+}
+
+func (s *snapshotStore) setExpectedPublication(maturity string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expected[maturity] = at
+}
+
+// delete forgets a series that is no longer polled
+func (s *snapshotStore) delete(key seriesKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.series[key]; exists {
+		delete(s.series, key)
+		s.touch(time.Now())
+	}
+}
+
+// markChanged records a change of the stored rates at the given time and
+// returns when they last changed
+func (s *snapshotStore) markChanged(at time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touch(at)
+	return s.changed
+}
+
+// touch moves the change time forward to at. Callers must hold s.mu.
+func (s *snapshotStore) touch(at time.Time) {
+	if at.After(s.changed) {
+		s.changed = at
+	}
+}
+
+// Describe implements prometheus.Collector
+func (e *EuriborExporter) Describe(ch chan<- *prometheus.Desc) {
+	rateDescs.describe(ch)
+	for _, descs := range legacyDescs {
+		descs.describe(ch)
+	}
+	ch <- lastSuccessDesc
+	ch <- lastAttemptDesc
+	ch <- seriesStaleDesc
+	ch <- seriesRestoredDesc
+	ch <- dailyPublicationsMissedDesc
+	ch <- scraperStrategyDesc
+	ch <- expectedPublicationDesc
+	ch <- sourceDivergenceDesc
+	ch <- sourceDivergenceCoverageDesc
+	ch <- loanPaymentDesc
+	ch <- loanEffectiveRateDesc
+	ch <- loanProjectedPaymentDesc
+	ch <- loanProjectedRateDesc
+	ch <- loanNextResetDesc
+
+	e.fetchCancellations.Describe(ch)
+	e.fetchErrors.Describe(ch)
+	e.validationRejections.Describe(ch)
+	e.fetchRetries.Describe(ch)
+	e.fetchesInFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. Rates whose last successful fetch
+// is older than the series TTL are left out and flagged as stale.
+func (e *EuriborExporter) Collect(ch chan<- prometheus.Metric) {
+	settings := e.current()
+	now := time.Now()
+
+	e.snapshots.mu.RLock()
+	defer e.snapshots.mu.RUnlock()
+
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		if desc != nil {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		}
+	}
+
+	for key, snap := range e.snapshots.series {
+		stale := snap.stale(now, settings.seriesTTL)
+		withRate := snap.hasRate && !stale
+
+		families := []seriesFamilies{{
+			descs:      rateDescs,
+			labels:     []string{key.source, key.maturity},
+			rateLabels: []string{key.source, key.maturity, frequencyLabel(snap.rate.Frequency)},
+		}}
+		if legacy, exists := legacyDescs[key.source]; exists && settings.legacyNames {
+			families = append(families, seriesFamilies{
+				descs:      legacy,
+				labels:     []string{key.maturity},
+				rateLabels: []string{key.maturity},
+			})
+		}
+
+		for _, f := range families {
+			gauge(f.descs.success, boolToFloat(snap.success), f.labels...)
+			gauge(f.descs.duration, snap.duration, f.labels...)
+			if withRate {
+				gauge(f.descs.rate, snap.rate.Rate, f.rateLabels...)
+				gauge(f.descs.pubDate, float64(snap.rate.PublicationDate.Unix()), f.rateLabels...)
+			}
+		}
+
+		gauge(lastAttemptDesc, float64(snap.lastAttempt.Unix()), key.source, key.maturity)
+		if snap.hasRate {
+			// Kept past the TTL so that alerts can tell how long fetches have been failing
+			gauge(lastSuccessDesc, float64(snap.lastSuccess.Unix()), key.source, key.maturity)
+			gauge(seriesStaleDesc, boolToFloat(stale), key.source, key.maturity)
+			gauge(seriesRestoredDesc, boolToFloat(snap.restored), key.source, key.maturity)
+		}
+		if snap.hasRate && key.source == sourceDaily {
+			// Kept past the TTL: this is what tells how old the data has become
+			gauge(dailyPublicationsMissedDesc, float64(settings.calendar.missed(snap.rate.PublicationDate, now)), key.maturity)
+		}
+		if key.source == sourceDaily && withRate {
+			for _, strategy := range scraper.Strategies() {
+				gauge(scraperStrategyDesc, boolToFloat(strategy == snap.strategy), key.maturity, strategy)
+			}
+		}
+	}
+
+	for maturity, at := range e.snapshots.expected {
+		if settings.maturities[maturity] {
+			gauge(expectedPublicationDesc, float64(at.Unix()), maturity)
+		}
+	}
+
+	for _, d := range e.reconciler.divergences() {
+		if settings.maturities[d.maturity] {
+			gauge(sourceDivergenceDesc, d.bp, d.maturity, d.period)
+			gauge(sourceDivergenceCoverageDesc, d.coverage, d.maturity, d.period)
+		}
+	}
+
+	// Loans follow the daily fixing of their reference maturity
+	for _, l := range settings.loans {
+		snap := e.snapshots.series[seriesKey{source: sourceDaily, maturity: l.maturity}]
+		if !snap.hasRate || snap.stale(now, settings.seriesTTL) {
+			continue
+		}
+
+		p := l.payments(snap.rate.Rate, now)
+		gauge(loanPaymentDesc, p.payment, l.name)
+		gauge(loanEffectiveRateDesc, p.effectiveRate, l.name)
+		gauge(loanProjectedPaymentDesc, p.projectedPayment, l.name)
+		gauge(loanProjectedRateDesc, p.projectedRate, l.name)
+		gauge(loanNextResetDesc, float64(p.nextReset.Unix()), l.name)
+	}
+
+	e.fetchCancellations.Collect(ch)
+	e.fetchErrors.Collect(ch)
+	e.validationRejections.Collect(ch)
+	e.fetchRetries.Collect(ch)
+	e.fetchesInFlight.Collect(ch)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// frequencyLabel names the frequency of a rate, "unknown" if the source did
+// not tell
+func frequencyLabel(frequency string) string {
+	if frequency == "" {
+		return "unknown"
+	}
+	return frequency
+}
@@ -0,0 +1,510 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the exporter configuration loaded from --config.file
+type Config struct {
+	Web        Web      `yaml:"web"`
+	Log        Log      `yaml:"log"`
+	HTTP       HTTP     `yaml:"http"`
+	Maturities []string `yaml:"maturities"`
+	Sources    Sources  `yaml:"sources"`
+
+	// Concurrency limits how many fetches run at the same time across all sources
+	Concurrency int `yaml:"concurrency"`
+
+	Retry          Retry          `yaml:"retry"`
+	CircuitBreaker CircuitBreaker `yaml:"circuit_breaker"`
+	Calendar       Calendar       `yaml:"calendar"`
+	Metrics        Metrics        `yaml:"metrics"`
+	Validation     Validation     `yaml:"validation"`
+	Storage        Storage        `yaml:"storage"`
+
+	// Loans are Euribor-linked loans whose payments are exported
+	Loans []Loan `yaml:"loans"`
+}
+
+// Web configures the HTTP server exposing metrics
+type Web struct {
+	ListenAddress string `yaml:"listen_address"`
+	MetricsPath   string `yaml:"metrics_path"`
+}
+
+// MinCalendarSeriesTTL is the shortest series TTL allowed while a source uses
+// the calendar schedule. Such sources are not polled between publications,
+// and the longest gap between TARGET publications, over Easter, is just
+// under five days.
+const MinCalendarSeriesTTL = 120 * time.Hour
+
+// Metrics configures how fetched series are exported
+type Metrics struct {
+	// SeriesTTL is how long after its last successful fetch a series keeps
+	// being exported; 0 keeps series forever
+	SeriesTTL time.Duration `yaml:"series_ttl"`
+
+	// LegacyNames also exports the metric names used before the unified
+	// schema, for dashboards and alerts that have not been migrated yet
+	LegacyNames bool `yaml:"legacy_names"`
+}
+
+// Log configures logging
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// HTTP holds settings shared by all upstream HTTP clients
+type HTTP struct {
+	UserAgent string `yaml:"user_agent"`
+}
+
+// Retry configures retries of transient upstream failures, shared by all sources
+type Retry struct {
+	MaxAttempts          int           `yaml:"max_attempts"`
+	BaseDelay            time.Duration `yaml:"base_delay"`
+	MaxDelay             time.Duration `yaml:"max_delay"`
+	Jitter               float64       `yaml:"jitter"`
+	RetryableStatusCodes []int         `yaml:"retryable_status_codes"`
+}
+
+// CircuitBreaker configures the per-source circuit breakers
+type CircuitBreaker struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // 0 disables the breakers
+	CoolDown         time.Duration `yaml:"cool_down"`
+}
+
+// Storage configures the state kept across restarts
+type Storage struct {
+	// Path is the file holding the last accepted rates and fetch history; empty
+	// keeps state in memory only
+	Path string `yaml:"path"`
+
+	// HistoryPath is the append-only file holding every distinct accepted rate,
+	// served by /api/v1/rates; empty keeps the history in memory only
+	HistoryPath string `yaml:"history_path"`
+}
+
+// Validation bounds the rates accepted from sources. Rejected rates are not
+// exported; the previous good value is kept.
+type Validation struct {
+	MinRate         float64 `yaml:"min_rate"`          // Lowest plausible rate in percent
+	MaxRate         float64 `yaml:"max_rate"`          // Highest plausible rate in percent
+	MaxJumpBP       float64 `yaml:"max_jump_bp"`       // Largest change over one business day in basis points; 0 disables
+	MaxDivergenceBP float64 `yaml:"max_divergence_bp"` // Largest difference to custom sources of the same frequency in basis points; 0 disables
+}
+
+// Amortization types of loans
+const (
+	AmortizationAnnuity = "annuity" // Equal monthly payments of interest and principal
+	AmortizationLinear  = "linear"  // Equal monthly principal repayments plus interest
+)
+
+// Loan describes a Euribor-linked loan as of today. Update the principal and
+// remaining term as the loan is repaid; a reload picks up the changes.
+type Loan struct {
+	Name            string   `yaml:"name"`
+	Principal       float64  `yaml:"principal"`        // Outstanding principal in EUR
+	Margin          float64  `yaml:"margin"`           // Percentage points added to the reference rate
+	Maturity        string   `yaml:"maturity"`         // Euribor maturity the rate is tied to
+	ReferenceRate   *float64 `yaml:"reference_rate"`   // Reference rate in percent fixed at the last reset; unset uses the current rate
+	NextReset       string   `yaml:"next_reset"`       // Date (YYYY-MM-DD) of the next reset; later resets follow every ResetMonths
+	ResetMonths     int      `yaml:"reset_months"`     // Months between resets
+	RemainingMonths int      `yaml:"remaining_months"` // Monthly payments left
+	Amortization    string   `yaml:"amortization"`
+}
+
+// NextResetDate returns the configured next reset date
+func (l Loan) NextResetDate() (time.Time, error) {
+	return time.Parse("2006-01-02", l.NextReset)
+}
+
+// Calendar configures when new fixings are expected. It drives sources with
+// the calendar schedule and the missed publications metric.
+type Calendar struct {
+	PublicationTime string        `yaml:"publication_time"` // Time of day (HH:MM) in Timezone
+	Timezone        string        `yaml:"timezone"`
+	PublicationLag  int           `yaml:"publication_lag"` // Business days between a fixing and its publication
+	Window          time.Duration `yaml:"window"`          // How long after the publication time to poll aggressively
+	WindowInterval  time.Duration `yaml:"window_interval"` // Poll interval inside the window
+}
+
+// Publication returns the configured publication time
+func (c Calendar) Publication() (calendar.Publication, error) {
+	return calendar.ParsePublication(c.PublicationTime, c.Timezone)
+}
+
+// Source schedules
+const (
+	ScheduleInterval = "interval" // Poll every interval
+	ScheduleCalendar = "calendar" // Poll around the expected publication time on TARGET business days
+)
+
+// Sources configures the rate sources
+type Sources struct {
+	Daily Source `yaml:"daily"`
+	ECB   Source `yaml:"ecb"`
+
+	// Custom configures sources registered with source.RegisterFactory, by
+	// the name they were registered under
+	Custom map[string]Source `yaml:"custom"`
+}
+
+// Source configures a single rate source
+type Source struct {
+	Enabled  bool              `yaml:"enabled"`
+	Interval time.Duration     `yaml:"interval"`
+	Timeout  time.Duration     `yaml:"timeout"`
+	Schedule string            `yaml:"schedule"`
+	URL      string            `yaml:"url,omitempty"`
+	Options  map[string]string `yaml:"options,omitempty"` // Passed to custom sources as is
+}
+
+// CustomNames returns the names of the custom sources ordered alphabetically
+func (s Sources) CustomNames() []string {
+	names := make([]string, 0, len(s.Custom))
+	for name := range s.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// enabled returns every enabled source by its configuration path
+func (s Sources) enabled() map[string]Source {
+	enabled := make(map[string]Source)
+	if s.Daily.Enabled {
+		enabled["sources.daily"] = s.Daily
+	}
+	if s.ECB.Enabled {
+		enabled["sources.ecb"] = s.ECB
+	}
+	for name, src := range s.Custom {
+		if src.Enabled {
+			enabled["sources.custom."+name] = src
+		}
+	}
+	return enabled
+}
+
+// Default returns the configuration used when no file is given
+func Default() *Config {
+	return &Config{
+		Web: Web{
+			ListenAddress: ":9100",
+			MetricsPath:   "/metrics",
+		},
+		Log: Log{
+			Level: "info",
+		},
+		HTTP: HTTP{
+			UserAgent: "euribor-exporter",
+		},
+		Maturities: []string{"1W", "1M", "3M", "6M", "12M"},
+		Sources: Sources{
+			Daily: Source{
+				Enabled:  true,
+				Interval: 1 * time.Hour,
+				Timeout:  30 * time.Second,
+				Schedule: ScheduleCalendar,
+			},
+			ECB: Source{
+				Enabled:  true,
+				Interval: 1 * time.Hour,
+				Timeout:  10 * time.Second,
+				Schedule: ScheduleInterval,
+				URL:      "https://data-api.ecb.europa.eu/service/data/FM",
+			},
+		},
+		Concurrency: 10,
+		Retry: Retry{
+			MaxAttempts:          3,
+			BaseDelay:            1 * time.Second,
+			MaxDelay:             10 * time.Second,
+			Jitter:               0.2,
+			RetryableStatusCodes: []int{429, 502, 503, 504},
+		},
+		CircuitBreaker: CircuitBreaker{
+			FailureThreshold: 5,
+			CoolDown:         5 * time.Minute,
+		},
+		Metrics: Metrics{
+			SeriesTTL: 7 * 24 * time.Hour,
+		},
+		Validation: Validation{
+			MinRate:         -2,
+			MaxRate:         10,
+			MaxJumpBP:       50,
+			MaxDivergenceBP: 0,
+		},
+		Calendar: Calendar{
+			PublicationTime: "11:00",
+			Timezone:        calendar.DefaultLocation,
+			PublicationLag:  1,
+			Window:          2 * time.Hour,
+			WindowInterval:  5 * time.Minute,
+		},
+	}
+}
+
+// Load reads the YAML file at path on top of the defaults. Unknown keys are
+// rejected so that typos do not silently fall back to defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv overrides configuration from environment variables:
+// LOG_LEVEL, ENABLE_ECB and SCRAPE_INTERVAL
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	if level, ok := lookup("LOG_LEVEL"); ok && level != "" {
+		c.Log.Level = level
+	}
+
+	// Anything but "false" keeps ECB enabled, as before config files existed
+	if enabled, ok := lookup("ENABLE_ECB"); ok && enabled != "" {
+		c.Sources.ECB.Enabled = enabled != "false"
+	}
+
+	if interval, ok := lookup("SCRAPE_INTERVAL"); ok && interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("invalid SCRAPE_INTERVAL %q: %w", interval, err)
+		}
+		c.SetInterval(d)
+	}
+
+	return nil
+}
+
+// SetInterval sets the polling interval of every source
+func (c *Config) SetInterval(d time.Duration) {
+	c.Sources.Daily.Interval = d
+	c.Sources.ECB.Interval = d
+	for name, src := range c.Sources.Custom {
+		src.Interval = d
+		c.Sources.Custom[name] = src
+	}
+}
+
+// Validate checks the configuration for errors and reports all of them at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Web.ListenAddress == "" {
+		errs = append(errs, fmt.Errorf("web.listen_address must not be empty"))
+	}
+	if !strings.HasPrefix(c.Web.MetricsPath, "/") {
+		errs = append(errs, fmt.Errorf("web.metrics_path must start with '/', got %q", c.Web.MetricsPath))
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	if len(c.Maturities) == 0 {
+		errs = append(errs, fmt.Errorf("maturities must not be empty"))
+	}
+	seen := make(map[string]bool)
+	for _, m := range c.Maturities {
+		if seen[m] {
+			errs = append(errs, fmt.Errorf("maturities: duplicate entry %q", m))
+		}
+		seen[m] = true
+	}
+
+	if c.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("concurrency must be at least 1, got %d", c.Concurrency))
+	}
+
+	errs = append(errs, c.Retry.validate()...)
+	errs = append(errs, c.CircuitBreaker.validate()...)
+
+	enabled := c.Sources.enabled()
+	if len(enabled) == 0 {
+		errs = append(errs, fmt.Errorf("sources: at least one source must be enabled"))
+	}
+	errs = append(errs, c.Sources.Daily.validate("sources.daily")...)
+	errs = append(errs, c.Sources.ECB.validate("sources.ecb")...)
+	for _, name := range c.Sources.CustomNames() {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("sources.custom: source name must not be empty"))
+			continue
+		}
+		errs = append(errs, c.Sources.Custom[name].validate("sources.custom."+name)...)
+	}
+
+	if c.Sources.ECB.Enabled && c.Sources.ECB.URL == "" {
+		errs = append(errs, fmt.Errorf("sources.ecb.url must not be empty"))
+	}
+
+	errs = append(errs, c.Calendar.validate()...)
+
+	if c.Metrics.SeriesTTL < 0 {
+		errs = append(errs, fmt.Errorf("metrics.series_ttl must not be negative, got %s", c.Metrics.SeriesTTL))
+	}
+	calendarScheduled := false
+	for _, src := range enabled {
+		calendarScheduled = calendarScheduled || src.Schedule == ScheduleCalendar
+	}
+	if calendarScheduled && c.Metrics.SeriesTTL > 0 && c.Metrics.SeriesTTL < MinCalendarSeriesTTL {
+		errs = append(errs, fmt.Errorf("metrics.series_ttl must be 0 or at least %s with the calendar schedule, which does not poll between publications, got %s",
+			MinCalendarSeriesTTL, c.Metrics.SeriesTTL))
+	}
+
+	errs = append(errs, c.Validation.validate()...)
+
+	names := make(map[string]bool)
+	for i, l := range c.Loans {
+		if names[l.Name] {
+			errs = append(errs, fmt.Errorf("loans: duplicate name %q", l.Name))
+		}
+		names[l.Name] = true
+		errs = append(errs, l.validate(fmt.Sprintf("loans[%d]", i), seen)...)
+	}
+	if len(c.Loans) > 0 && !c.Sources.Daily.Enabled {
+		errs = append(errs, fmt.Errorf("loans: the daily source must be enabled to follow the reference rates"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s Source) validate(prefix string) []error {
+	var errs []error
+
+	if s.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("%s.interval must be positive, got %s", prefix, s.Interval))
+	}
+	if s.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("%s.timeout must be positive, got %s", prefix, s.Timeout))
+	}
+	if s.Schedule != ScheduleInterval && s.Schedule != ScheduleCalendar {
+		errs = append(errs, fmt.Errorf("%s.schedule must be %q or %q, got %q", prefix, ScheduleInterval, ScheduleCalendar, s.Schedule))
+	}
+
+	return errs
+}
+
+// validate checks a loan; maturities holds the enabled maturities
+func (l Loan) validate(prefix string, maturities map[string]bool) []error {
+	var errs []error
+
+	if l.Name == "" {
+		errs = append(errs, fmt.Errorf("%s.name must not be empty", prefix))
+	}
+	if l.Principal <= 0 {
+		errs = append(errs, fmt.Errorf("%s.principal must be positive, got %g", prefix, l.Principal))
+	}
+	if !maturities[l.Maturity] {
+		errs = append(errs, fmt.Errorf("%s.maturity must be one of the enabled maturities, got %q", prefix, l.Maturity))
+	}
+	if _, err := l.NextResetDate(); err != nil {
+		errs = append(errs, fmt.Errorf("%s.next_reset must be a date (YYYY-MM-DD), got %q", prefix, l.NextReset))
+	}
+	if l.ResetMonths < 1 {
+		errs = append(errs, fmt.Errorf("%s.reset_months must be at least 1, got %d", prefix, l.ResetMonths))
+	}
+	if l.RemainingMonths < 1 {
+		errs = append(errs, fmt.Errorf("%s.remaining_months must be at least 1, got %d", prefix, l.RemainingMonths))
+	}
+	if l.Amortization != AmortizationAnnuity && l.Amortization != AmortizationLinear {
+		errs = append(errs, fmt.Errorf("%s.amortization must be %q or %q, got %q", prefix, AmortizationAnnuity, AmortizationLinear, l.Amortization))
+	}
+
+	return errs
+}
+
+func (r Retry) validate() []error {
+	var errs []error
+
+	if r.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("retry.max_attempts must be at least 1, got %d", r.MaxAttempts))
+	}
+	if r.BaseDelay <= 0 {
+		errs = append(errs, fmt.Errorf("retry.base_delay must be positive, got %s", r.BaseDelay))
+	}
+	if r.MaxDelay < r.BaseDelay {
+		errs = append(errs, fmt.Errorf("retry.max_delay (%s) must not be less than retry.base_delay (%s)", r.MaxDelay, r.BaseDelay))
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		errs = append(errs, fmt.Errorf("retry.jitter must be between 0 and 1, got %g", r.Jitter))
+	}
+	for _, code := range r.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, fmt.Errorf("retry.retryable_status_codes: invalid HTTP status %d", code))
+		}
+	}
+
+	return errs
+}
+
+func (b CircuitBreaker) validate() []error {
+	var errs []error
+
+	if b.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.failure_threshold must not be negative, got %d", b.FailureThreshold))
+	}
+	if b.FailureThreshold > 0 && b.CoolDown <= 0 {
+		errs = append(errs, fmt.Errorf("circuit_breaker.cool_down must be positive, got %s", b.CoolDown))
+	}
+
+	return errs
+}
+
+func (v Validation) validate() []error {
+	var errs []error
+
+	if v.MaxRate <= v.MinRate {
+		errs = append(errs, fmt.Errorf("validation.max_rate (%g) must be greater than validation.min_rate (%g)", v.MaxRate, v.MinRate))
+	}
+	if v.MaxJumpBP < 0 {
+		errs = append(errs, fmt.Errorf("validation.max_jump_bp must not be negative, got %g", v.MaxJumpBP))
+	}
+	if v.MaxDivergenceBP < 0 {
+		errs = append(errs, fmt.Errorf("validation.max_divergence_bp must not be negative, got %g", v.MaxDivergenceBP))
+	}
+
+	return errs
+}
+
+func (c Calendar) validate() []error {
+	var errs []error
+
+	if _, err := c.Publication(); err != nil {
+		errs = append(errs, fmt.Errorf("calendar: %w", err))
+	}
+	if c.PublicationLag < 0 {
+		errs = append(errs, fmt.Errorf("calendar.publication_lag must not be negative, got %d", c.PublicationLag))
+	}
+	if c.Window <= 0 {
+		errs = append(errs, fmt.Errorf("calendar.window must be positive, got %s", c.Window))
+	}
+	if c.WindowInterval <= 0 {
+		errs = append(errs, fmt.Errorf("calendar.window_interval must be positive, got %s", c.WindowInterval))
+	}
+
+	return errs
+}
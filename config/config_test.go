@@ -0,0 +1,259 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Default().Validate() = %v, want nil", err)
+	}
+}
+
+func TestLoadMergesWithDefaults(t *testing.T) {
+	path := writeConfig(t, `
+web:
+  listen_address: ":9200"
+maturities: [3M, 12M]
+sources:
+  ecb:
+    enabled: false
+  daily:
+    interval: 30m
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if cfg.Web.ListenAddress != ":9200" {
+		t.Errorf("ListenAddress = %q, want :9200", cfg.Web.ListenAddress)
+	}
+	if cfg.Web.MetricsPath != "/metrics" {
+		t.Errorf("MetricsPath = %q, want default /metrics", cfg.Web.MetricsPath)
+	}
+	if strings.Join(cfg.Maturities, ",") != "3M,12M" {
+		t.Errorf("Maturities = %v, want [3M 12M]", cfg.Maturities)
+	}
+	if cfg.Sources.ECB.Enabled {
+		t.Error("Sources.ECB.Enabled = true, want false")
+	}
+	if !cfg.Sources.Daily.Enabled {
+		t.Error("Sources.Daily.Enabled = false, want default true")
+	}
+	if cfg.Sources.Daily.Interval != 30*time.Minute {
+		t.Errorf("Sources.Daily.Interval = %s, want 30m", cfg.Sources.Daily.Interval)
+	}
+	if cfg.Sources.Daily.Timeout != 30*time.Second {
+		t.Errorf("Sources.Daily.Timeout = %s, want default 30s", cfg.Sources.Daily.Timeout)
+	}
+}
+
+func TestLoadLoans(t *testing.T) {
+	path := writeConfig(t, `
+loans:
+  - name: home
+    principal: 200000
+    margin: 0.6
+    maturity: 12M
+    reference_rate: 2.1
+    next_reset: 2026-03-01
+    reset_months: 12
+    remaining_months: 240
+    amortization: annuity
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+
+	if len(cfg.Loans) != 1 {
+		t.Fatalf("Loans = %+v, want 1 loan", cfg.Loans)
+	}
+	loan := cfg.Loans[0]
+	if loan.ReferenceRate == nil || *loan.ReferenceRate != 2.1 {
+		t.Errorf("ReferenceRate = %v, want 2.1", loan.ReferenceRate)
+	}
+	if reset, _ := loan.NextResetDate(); !reset.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextResetDate() = %s, want 2026-03-01", reset)
+	}
+}
+
+func TestLoadCustomSources(t *testing.T) {
+	path := writeConfig(t, `
+sources:
+  daily:
+    enabled: false
+  ecb:
+    enabled: false
+  custom:
+    internal:
+      enabled: true
+      interval: 15m
+      timeout: 5s
+      schedule: interval
+      url: https://rates.example.com
+      options:
+        desk: treasury
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() with only a custom source: %v", err)
+	}
+
+	internal := cfg.Sources.Custom["internal"]
+	if !internal.Enabled || internal.Interval != 15*time.Minute || internal.URL != "https://rates.example.com" ||
+		internal.Options["desk"] != "treasury" {
+		t.Errorf("Sources.Custom[internal] = %+v, want the configured source", internal)
+	}
+
+	cfg.SetInterval(time.Hour)
+	if got := cfg.Sources.Custom["internal"].Interval; got != time.Hour {
+		t.Errorf("custom interval after SetInterval = %s, want 1h", got)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := writeConfig(t, "web:\n  listen_adress: \":9200\"\n")
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() with misspelled key: expected error, got nil")
+	}
+}
+
+func TestLoadEmptyFile(t *testing.T) {
+	cfg, err := Load(writeConfig(t, ""))
+	if err != nil {
+		t.Fatalf("Load() of empty file unexpected error: %v", err)
+	}
+	if cfg.Web.ListenAddress != ":9100" {
+		t.Errorf("ListenAddress = %q, want default :9100", cfg.Web.ListenAddress)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"LOG_LEVEL":       "debug",
+		"ENABLE_ECB":      "false",
+		"SCRAPE_INTERVAL": "15m",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := Default()
+	if err := cfg.ApplyEnv(lookup); err != nil {
+		t.Fatalf("ApplyEnv() unexpected error: %v", err)
+	}
+
+	if cfg.Log.Level != "debug" {
+		t.Errorf("Log.Level = %q, want debug", cfg.Log.Level)
+	}
+	if cfg.Sources.ECB.Enabled {
+		t.Error("Sources.ECB.Enabled = true, want false")
+	}
+	if cfg.Sources.Daily.Interval != 15*time.Minute || cfg.Sources.ECB.Interval != 15*time.Minute {
+		t.Errorf("intervals = %s/%s, want 15m", cfg.Sources.Daily.Interval, cfg.Sources.ECB.Interval)
+	}
+
+	env["SCRAPE_INTERVAL"] = "hourly"
+	if err := Default().ApplyEnv(lookup); err == nil {
+		t.Error("ApplyEnv() with invalid SCRAPE_INTERVAL: expected error, got nil")
+	}
+}
+
+func validLoan() Loan {
+	return Loan{
+		Name:            "home",
+		Principal:       200000,
+		Margin:          0.6,
+		Maturity:        "12M",
+		NextReset:       "2026-03-01",
+		ResetMonths:     12,
+		RemainingMonths: 240,
+		Amortization:    AmortizationAnnuity,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"empty listen address", func(c *Config) { c.Web.ListenAddress = "" }},
+		{"relative metrics path", func(c *Config) { c.Web.MetricsPath = "metrics" }},
+		{"bad log level", func(c *Config) { c.Log.Level = "verbose" }},
+		{"no maturities", func(c *Config) { c.Maturities = nil }},
+		{"duplicate maturity", func(c *Config) { c.Maturities = []string{"3M", "3M"} }},
+		{"no sources", func(c *Config) { c.Sources.Daily.Enabled = false; c.Sources.ECB.Enabled = false }},
+		{"zero concurrency", func(c *Config) { c.Concurrency = 0 }},
+		{"zero retry attempts", func(c *Config) { c.Retry.MaxAttempts = 0 }},
+		{"max delay below base", func(c *Config) { c.Retry.MaxDelay = c.Retry.BaseDelay / 2 }},
+		{"jitter above one", func(c *Config) { c.Retry.Jitter = 1.5 }},
+		{"invalid status code", func(c *Config) { c.Retry.RetryableStatusCodes = []int{999} }},
+		{"negative failure threshold", func(c *Config) { c.CircuitBreaker.FailureThreshold = -1 }},
+		{"zero cool-down", func(c *Config) { c.CircuitBreaker.CoolDown = 0 }},
+		{"unknown schedule", func(c *Config) { c.Sources.ECB.Schedule = "cron" }},
+		{"bad publication time", func(c *Config) { c.Calendar.PublicationTime = "11am" }},
+		{"unknown time zone", func(c *Config) { c.Calendar.Timezone = "CET/Brussels" }},
+		{"negative publication lag", func(c *Config) { c.Calendar.PublicationLag = -1 }},
+		{"zero window interval", func(c *Config) { c.Calendar.WindowInterval = 0 }},
+		{"negative series TTL", func(c *Config) { c.Metrics.SeriesTTL = -time.Hour }},
+		{"series TTL shorter than calendar gaps", func(c *Config) { c.Metrics.SeriesTTL = 24 * time.Hour }},
+		{"max rate not above min", func(c *Config) { c.Validation.MaxRate = c.Validation.MinRate }},
+		{"negative jump threshold", func(c *Config) { c.Validation.MaxJumpBP = -1 }},
+		{"negative divergence tolerance", func(c *Config) { c.Validation.MaxDivergenceBP = -1 }},
+		{"zero interval", func(c *Config) { c.Sources.Daily.Interval = 0 }},
+		{"negative timeout", func(c *Config) { c.Sources.ECB.Timeout = -time.Second }},
+		{"missing ECB url", func(c *Config) { c.Sources.ECB.URL = "" }},
+		{"unnamed loan", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].Name = "" }},
+		{"duplicate loan", func(c *Config) { c.Loans = []Loan{validLoan(), validLoan()} }},
+		{"zero principal", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].Principal = 0 }},
+		{"loan maturity not enabled", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Maturities = []string{"3M"} }},
+		{"bad next reset", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].NextReset = "1.3.2026" }},
+		{"zero reset months", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].ResetMonths = 0 }},
+		{"zero remaining months", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].RemainingMonths = 0 }},
+		{"unknown amortization", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Loans[0].Amortization = "bullet" }},
+		{"loan without daily source", func(c *Config) { c.Loans = []Loan{validLoan()}; c.Sources.Daily.Enabled = false }},
+		{"custom source without interval", func(c *Config) {
+			c.Sources.Custom = map[string]Source{"internal": {Enabled: true, Timeout: time.Second, Schedule: ScheduleInterval}}
+		}},
+		{"unnamed custom source", func(c *Config) {
+			c.Sources.Custom = map[string]Source{"": {Enabled: true, Interval: time.Hour, Timeout: time.Second, Schedule: ScheduleInterval}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("Validate() expected error, got nil")
+			}
+		})
+	}
+}
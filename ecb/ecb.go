@@ -0,0 +1,276 @@
+package ecb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBaseURL is the ECB Data Portal endpoint for the FM (financial markets) dataflow
+	DefaultBaseURL = "https://data-api.ecb.europa.eu/service/data/FM"
+)
+
+// ErrNoData is returned when the ECB API has no observations for the requested series
+var ErrNoData = errors.New("no data available")
+
+// Frequency is the SDMX FREQ dimension of a Euribor series
+type Frequency string
+
+const (
+	Monthly Frequency = "M"
+	Daily   Frequency = "D"
+)
+
+// Maturity codes mapping
+var maturityCodes = map[string]string{
+	"1M":  "1MD_",
+	"3M":  "3MD_",
+	"6M":  "6MD_",
+	"12M": "1YD_",
+}
+
+// Observation is a single value of an ECB Euribor series
+type Observation struct {
+	Value  float64
+	Period string    // Raw SDMX time period, e.g. "2025-11" or "2025-11-14"
+	Date   time.Time // Last day covered by Period (UTC)
+	Status string    // SDMX OBS_STATUS code, e.g. "A" for normal value
+}
+
+// Client fetches Euribor rates from the ECB SDMX REST API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	log        *logrus.Logger
+}
+
+// NewClient creates a new ECB client. An empty baseURL selects DefaultBaseURL
+// and a nil httpClient selects a client with a 10 second timeout.
+func NewClient(baseURL string, httpClient *http.Client, log *logrus.Logger) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+		log:        log,
+	}
+}
+
+// SupportedMaturities returns the maturities published by the ECB
+func SupportedMaturities() []string {
+	maturities := make([]string, 0, len(maturityCodes))
+	for m := range maturityCodes {
+		maturities = append(maturities, m)
+	}
+	sort.Strings(maturities)
+	return maturities
+}
+
+// SeriesKey returns the SDMX series key of the Euribor series for maturity
+func SeriesKey(freq Frequency, maturity string) (string, error) {
+	code, exists := maturityCodes[maturity]
+	if !exists {
+		return "", fmt.Errorf("invalid maturity: %s", maturity)
+	}
+	return fmt.Sprintf("%s.U2.EUR.RT.MM.EURIBOR%s.HSTA", freq, code), nil
+}
+
+// FetchLatest fetches the most recent monthly observation for maturity
+func (c *Client) FetchLatest(ctx context.Context, maturity string) (*Observation, error) {
+	key, err := SeriesKey(Monthly, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s?format=jsondata&lastNObservations=1", c.baseURL, key)
+
+	c.log.WithFields(logrus.Fields{
+		"maturity": maturity,
+		"url":      url,
+	}).Debug("Fetching Euribor rate from ECB")
+
+	observations, err := c.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := observations[len(observations)-1]
+
+	c.log.WithFields(logrus.Fields{
+		"maturity": maturity,
+		"period":   latest.Period,
+		"status":   latest.Status,
+	}).Debug("Parsed ECB observation")
+
+	return &latest, nil
+}
+
+// FetchSeries fetches every observation of the maturity series at freq between
+// from and to (inclusive), ordered by period
+func (c *Client) FetchSeries(ctx context.Context, freq Frequency, maturity string, from, to time.Time) ([]Observation, error) {
+	key, err := SeriesKey(freq, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := "2006-01-02"
+	if freq == Monthly {
+		layout = "2006-01"
+	}
+
+	url := fmt.Sprintf("%s/%s?format=jsondata&startPeriod=%s&endPeriod=%s",
+		c.baseURL, key, from.Format(layout), to.Format(layout))
+
+	c.log.WithFields(logrus.Fields{
+		"maturity":  maturity,
+		"frequency": string(freq),
+		"url":       url,
+	}).Debug("Fetching Euribor series from ECB")
+
+	return c.fetch(ctx, url)
+}
+
+// fetch performs the HTTP request and decodes the SDMX-JSON body
+func (c *Client) fetch(ctx context.Context, url string) ([]Observation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fetcherr.Request(ctx, fmt.Errorf("failed to fetch data: %w", err))
+	}
+	defer resp.Body.Close()
+
+	// The API answers 404 when a query matches no observations
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fetcherr.Errorf(fetcherr.EmptySeries, "%w: ECB API returned status %d", ErrNoData, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB API: %w", fetcherr.Status(resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fetcherr.Request(ctx, fmt.Errorf("failed to read response: %w", err))
+	}
+
+	return parseResponse(body)
+}
+
+// parseResponse decodes an SDMX-JSON message holding a single series and
+// returns its observations ordered by period
+func parseResponse(body []byte) ([]Observation, error) {
+	var msg response
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fetcherr.Errorf(fetcherr.ParseResponse, "failed to parse JSON: %w", err)
+	}
+
+	if len(msg.DataSets) == 0 {
+		return nil, fetcherr.Errorf(fetcherr.EmptySeries, "no datasets in response")
+	}
+
+	if len(msg.DataSets[0].Series) == 0 {
+		return nil, fetcherr.Errorf(fetcherr.EmptySeries, "series not found in response")
+	}
+	if len(msg.DataSets[0].Series) > 1 {
+		return nil, fetcherr.Errorf(fetcherr.ParseResponse, "expected a single series, got %d", len(msg.DataSets[0].Series))
+	}
+
+	var s series
+	for _, v := range msg.DataSets[0].Series {
+		s = v
+	}
+
+	if len(s.Observations) == 0 {
+		return nil, fetcherr.Errorf(fetcherr.EmptySeries, "no observations in series")
+	}
+
+	timeDim, err := msg.Structure.Dimensions.timeDimension()
+	if err != nil {
+		return nil, fetcherr.New(fetcherr.ParseResponse, err)
+	}
+	statusAttr := msg.Structure.Attributes.observationAttribute("OBS_STATUS")
+
+	observations := make([]Observation, 0, len(s.Observations))
+	for key, values := range s.Observations {
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(timeDim.Values) {
+			return nil, fetcherr.Errorf(fetcherr.ParseResponse, "observation key %q does not match time dimension", key)
+		}
+
+		// Missing values are encoded as null
+		if len(values) == 0 || values[0] == nil {
+			continue
+		}
+
+		period := timeDim.Values[idx].ID
+		date, err := parsePeriod(period)
+		if err != nil {
+			return nil, fetcherr.New(fetcherr.ParseDate, err)
+		}
+
+		obs := Observation{
+			Value:  *values[0],
+			Period: period,
+			Date:   date,
+		}
+
+		// Observation attributes follow the value, in structure order
+		if statusAttr >= 0 && len(values) > statusAttr+1 && values[statusAttr+1] != nil {
+			valueIdx := int(*values[statusAttr+1])
+			attrValues := msg.Structure.Attributes.Observation[statusAttr].Values
+			if valueIdx >= 0 && valueIdx < len(attrValues) {
+				obs.Status = attrValues[valueIdx].ID
+			}
+		}
+
+		observations = append(observations, obs)
+	}
+
+	if len(observations) == 0 {
+		return nil, fetcherr.Errorf(fetcherr.EmptySeries, "observation is empty")
+	}
+
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Date.Before(observations[j].Date)
+	})
+
+	return observations, nil
+}
+
+// parsePeriod converts an SDMX time period into the last day it covers
+func parsePeriod(period string) (time.Time, error) {
+	// Monthly series: "2025-11"
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	// Daily series: "2025-11-14"
+	if t, err := time.Parse("2006-01-02", period); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time period %q", period)
+}
@@ -0,0 +1,199 @@
+package ecb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
+	"github.com/sirupsen/logrus"
+)
+
+// newFixtureServer serves the named testdata fixture for every request and
+// records the last requested path
+func newFixtureServer(t *testing.T, fixture string, status int) (*httptest.Server, *string) {
+	t.Helper()
+
+	var body []byte
+	if fixture != "" {
+		var err error
+		body, err = os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatalf("failed to read fixture %s: %v", fixture, err)
+		}
+	}
+
+	var lastPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastPath = r.URL.Path + "?" + r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &lastPath
+}
+
+func newTestClient(baseURL string) *Client {
+	log := logrus.New()
+	log.SetLevel(logrus.WarnLevel)
+	return NewClient(baseURL, nil, log)
+}
+
+func TestFetchLatest(t *testing.T) {
+	srv, lastPath := newFixtureServer(t, "euribor3m_latest.json", http.StatusOK)
+	c := newTestClient(srv.URL)
+
+	obs, err := c.FetchLatest(context.Background(), "3M")
+	if err != nil {
+		t.Fatalf("FetchLatest(3M) unexpected error: %v", err)
+	}
+
+	wantPath := "/M.U2.EUR.RT.MM.EURIBOR3MD_.HSTA?format=jsondata&lastNObservations=1"
+	if *lastPath != wantPath {
+		t.Errorf("requested %q, want %q", *lastPath, wantPath)
+	}
+
+	if obs.Value != 2.0417 {
+		t.Errorf("Value = %v, want 2.0417", obs.Value)
+	}
+	if obs.Period != "2025-11" {
+		t.Errorf("Period = %q, want 2025-11", obs.Period)
+	}
+	if want := time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC); !obs.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", obs.Date, want)
+	}
+	if obs.Status != "A" {
+		t.Errorf("Status = %q, want A", obs.Status)
+	}
+}
+
+func TestFetchLatestPicksMostRecentPeriod(t *testing.T) {
+	srv, _ := newFixtureServer(t, "euribor12m_provisional.json", http.StatusOK)
+	c := newTestClient(srv.URL)
+
+	obs, err := c.FetchLatest(context.Background(), "12M")
+	if err != nil {
+		t.Fatalf("FetchLatest(12M) unexpected error: %v", err)
+	}
+
+	if obs.Period != "2025-11" || obs.Value != 2.2176 {
+		t.Errorf("got %s = %v, want 2025-11 = 2.2176", obs.Period, obs.Value)
+	}
+	if obs.Status != "P" {
+		t.Errorf("Status = %q, want P", obs.Status)
+	}
+}
+
+func TestFetchSeries(t *testing.T) {
+	srv, lastPath := newFixtureServer(t, "euribor12m_provisional.json", http.StatusOK)
+	c := newTestClient(srv.URL)
+
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC)
+
+	observations, err := c.FetchSeries(context.Background(), Monthly, "12M", from, to)
+	if err != nil {
+		t.Fatalf("FetchSeries(12M) unexpected error: %v", err)
+	}
+
+	wantPath := "/M.U2.EUR.RT.MM.EURIBOR1YD_.HSTA?format=jsondata&startPeriod=2025-10&endPeriod=2025-11"
+	if *lastPath != wantPath {
+		t.Errorf("requested %q, want %q", *lastPath, wantPath)
+	}
+
+	if len(observations) != 2 {
+		t.Fatalf("got %d observations, want 2", len(observations))
+	}
+	if observations[0].Period != "2025-10" || observations[1].Period != "2025-11" {
+		t.Errorf("observations not ordered by period: %s, %s", observations[0].Period, observations[1].Period)
+	}
+}
+
+func TestFetchSeriesNoData(t *testing.T) {
+	srv, _ := newFixtureServer(t, "", http.StatusNotFound)
+	c := newTestClient(srv.URL)
+
+	day := time.Date(2025, 11, 14, 0, 0, 0, 0, time.UTC)
+	_, err := c.FetchSeries(context.Background(), Daily, "3M", day, day)
+	if !errors.Is(err, ErrNoData) {
+		t.Errorf("FetchSeries() error = %v, want ErrNoData", err)
+	}
+}
+
+func TestFetchLatestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		status   int
+		maturity string
+		reason   fetcherr.Reason
+	}{
+		{"invalid maturity", "euribor3m_latest.json", http.StatusOK, "1W", fetcherr.Unknown},
+		{"not found", "", http.StatusNotFound, "3M", fetcherr.EmptySeries},
+		{"server error", "", http.StatusInternalServerError, "3M", fetcherr.HTTPStatus},
+		{"empty series", "empty_series.json", http.StatusOK, "3M", fetcherr.EmptySeries},
+		{"empty body", "", http.StatusOK, "3M", fetcherr.ParseResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newFixtureServer(t, tt.fixture, tt.status)
+			c := newTestClient(srv.URL)
+
+			_, err := c.FetchLatest(context.Background(), tt.maturity)
+			if err == nil {
+				t.Fatalf("FetchLatest(%s) expected error, got nil", tt.maturity)
+			}
+			if got := fetcherr.ReasonOf(err); got != tt.reason {
+				t.Errorf("FetchLatest(%s) error reason = %q, want %q", tt.maturity, got, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2025-11", time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC), false},
+		{"2024-02", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"2025-12", time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"2025-11-14", time.Date(2025, 11, 14, 0, 0, 0, 0, time.UTC), false},
+		{"2025-Q4", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parsePeriod(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePeriod(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePeriod(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedMaturities(t *testing.T) {
+	got := SupportedMaturities()
+	want := []string{"12M", "1M", "3M", "6M"}
+
+	if len(got) != len(want) {
+		t.Fatalf("SupportedMaturities() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SupportedMaturities()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ecb
+
+import "fmt"
+
+// SDMX-JSON response structures
+type response struct {
+	DataSets  []dataSet `json:"dataSets"`
+	Structure structure `json:"structure"`
+}
+
+type dataSet struct {
+	Series map[string]series `json:"series"`
+}
+
+type series struct {
+	// Each observation is [value, attribute indexes...], any of which may be null
+	Observations map[string][]*float64 `json:"observations"`
+}
+
+type structure struct {
+	Dimensions dimensions `json:"dimensions"`
+	Attributes attributes `json:"attributes"`
+}
+
+type dimensions struct {
+	Observation []component `json:"observation"`
+}
+
+type attributes struct {
+	Observation []component `json:"observation"`
+}
+
+type component struct {
+	ID     string           `json:"id"`
+	Values []componentValue `json:"values"`
+}
+
+type componentValue struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// timeDimension returns the TIME_PERIOD observation dimension
+func (d dimensions) timeDimension() (component, error) {
+	for _, dim := range d.Observation {
+		if dim.ID == "TIME_PERIOD" {
+			return dim, nil
+		}
+	}
+
+	// Older responses omit the ID; the time dimension is always the only one
+	if len(d.Observation) == 1 {
+		return d.Observation[0], nil
+	}
+
+	return component{}, fmt.Errorf("time dimension not found in response")
+}
+
+// observationAttribute returns the position of the observation attribute id, or -1
+func (a attributes) observationAttribute(id string) int {
+	for i, attr := range a.Observation {
+		if attr.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+)
+
+// csvHeader lists the columns of /export.csv
+var csvHeader = []string{"date", "maturity", "source", "rate"}
+
+// exportCSVHandler serves the rate history as a CSV download, filtered by the
+// same parameters as /api/v1/rates
+func exportCSVHandler(e *EuriborExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Only GET or HEAD requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q, err := parseHistoryQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="euribor-rates.csv"`)
+
+		out := csv.NewWriter(w)
+		out.Write(csvHeader)
+		for _, o := range e.history.Query(q) {
+			out.Write([]string{
+				o.PublicationDate.Format(apiDateFormat),
+				o.Maturity,
+				o.Source,
+				strconv.FormatFloat(o.Rate, 'f', -1, 64),
+			})
+		}
+
+		out.Flush()
+		if err := out.Error(); err != nil {
+			log.WithError(err).Debug("Failed to write CSV export")
+		}
+	}
+}
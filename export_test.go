@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExportCSVHandler(t *testing.T) {
+	exporter, _ := newTestExporter(t, &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081, "12M": 2.264},
+	}, time.Hour, false)
+	poll(exporter)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "all",
+			wantStatus: http.StatusOK,
+			wantBody:   "date,maturity,source,rate\n2025-12-15,12M,fake,2.264\n2025-12-15,3M,fake,2.081\n",
+		},
+		{
+			name:       "maturity and range",
+			query:      "?maturity=3M&from=2025-12-01&to=2025-12-31",
+			wantStatus: http.StatusOK,
+			wantBody:   "date,maturity,source,rate\n2025-12-15,3M,fake,2.081\n",
+		},
+		{
+			name:       "no match keeps the header",
+			query:      "?from=2025-12-16",
+			wantStatus: http.StatusOK,
+			wantBody:   "date,maturity,source,rate\n",
+		},
+		{
+			name:       "bad date",
+			query:      "?to=2025-13-01",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			exportCSVHandler(exporter)(rec, httptest.NewRequest(http.MethodGet, "/export.csv"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want text/csv", got)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
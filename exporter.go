@@ -0,0 +1,650 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/breaker"
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
+	"github.com/GoGstickGo/euribor-exporter/history"
+	"github.com/GoGstickGo/euribor-exporter/retry"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// Defaults for sources without configured options
+const (
+	defaultInterval    = 1 * time.Hour
+	defaultTimeout     = 30 * time.Second
+	defaultConcurrency = 10
+)
+
+// Cancellation causes attached to fetch contexts, reported in logs and metrics
+var (
+	errShutdown      = errors.New("shutdown")
+	errReload        = errors.New("reload")
+	errFetchDeadline = errors.New("deadline")
+)
+
+// sourceOptions holds the scheduling options of one source
+type sourceOptions struct {
+	interval time.Duration // Time between polls
+	timeout  time.Duration // Deadline for a single fetch
+	schedule *pollSchedule // Publication-aware schedule; nil polls every interval
+}
+
+// EuriborExporter fetches Euribor rates from multiple sources and exposes
+// them as a prometheus.Collector
+type EuriborExporter struct {
+	mu       sync.RWMutex
+	settings exporterSettings
+
+	snapshots  *snapshotStore // Latest fetch results, read on every collection
+	reconciler *reconciler    // Monthly averages of observed daily fixings
+	history    *history.Store // Every distinct accepted rate, served by /api/v1/rates
+
+	stateMu   sync.Mutex
+	statePath string // File the state is saved to; empty keeps it in memory only
+
+	fetchCancellations *prometheus.CounterVec
+	fetchErrors        *prometheus.CounterVec
+	fetchRetries       *prometheus.CounterVec
+	fetchesInFlight    prometheus.Gauge
+
+	validationRejections *prometheus.CounterVec
+
+	reloadCh chan struct{} // Signals Run to apply new settings to the pollers
+
+	pollMu     sync.Mutex
+	pollStates map[string]pollState // By source name, kept across poller restarts
+}
+
+// exporterConfig holds everything needed to create or reload an exporter
+type exporterConfig struct {
+	sources     *source.Registry
+	options     map[string]sourceOptions // Scheduling options per source name
+	maturities  []string
+	concurrency int
+	calendar    publicationCalendar
+	seriesTTL   time.Duration
+	legacyNames bool
+	validation  validationPolicy
+	loans       []loan
+}
+
+// exporterSettings is the part of the exporter configuration that can be
+// swapped at runtime
+type exporterSettings struct {
+	sources     *source.Registry
+	options     map[string]sourceOptions // Scheduling options per source name
+	maturities  map[string]bool          // Maturities to fetch; sources skip the rest
+	concurrency int                      // Maximum fetches in flight across all sources
+	calendar    publicationCalendar      // When new fixings are expected
+	seriesTTL   time.Duration            // Age after which series stop being exported; 0 disables
+	legacyNames bool                     // Also export the metric names used before the unified schema
+	validation  validationPolicy         // Plausibility checks applied before a rate is exported
+	loans       []loan                   // Loans whose payments are exported
+	slots       chan struct{}            // Fetch slots shared by all pollers, concurrency in size
+}
+
+// NewEuriborExporter creates a new exporter instance polling the registered sources
+func NewEuriborExporter(cfg exporterConfig) *EuriborExporter {
+	return &EuriborExporter{
+		settings:   newExporterSettings(cfg),
+		snapshots:  newSnapshotStore(),
+		reconciler: newReconciler(),
+		history:    history.New(),
+
+		fetchCancellations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "fetch_cancellations_total",
+				Help:      "Fetches aborted before completion, by reason (deadline, reload, shutdown)",
+			},
+			[]string{"source", "maturity", "reason"},
+		),
+		fetchErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "fetch_errors_total",
+				Help:      "Failed fetches by reason (network, timeout, http_status, parse_html, parse_response, parse_rate, parse_date, empty_series, unknown)",
+			},
+			[]string{"source", "maturity", "reason"},
+		),
+		validationRejections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "validation_rejections_total",
+				Help:      "Fetched rates rejected as implausible, by rule (bounds, jump, divergence)",
+			},
+			[]string{"source", "maturity", "rule"},
+		),
+		fetchRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "fetch_retries_total",
+				Help:      "Number of retried upstream requests after transient failures",
+			},
+			[]string{"source", "maturity"},
+		),
+		fetchesInFlight: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "fetches_in_flight",
+				Help:      "Number of fetches currently running",
+			},
+		),
+
+		reloadCh:   make(chan struct{}, 1),
+		pollStates: make(map[string]pollState),
+	}
+}
+
+func newExporterSettings(cfg exporterConfig) exporterSettings {
+	enabled := make(map[string]bool, len(cfg.maturities))
+	for _, m := range cfg.maturities {
+		enabled[m] = true
+	}
+
+	concurrency := cfg.concurrency
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+
+	return exporterSettings{
+		sources:     cfg.sources,
+		options:     cfg.options,
+		maturities:  enabled,
+		concurrency: concurrency,
+		calendar:    cfg.calendar,
+		seriesTTL:   cfg.seriesTTL,
+		legacyNames: cfg.legacyNames,
+		validation:  cfg.validation,
+		loans:       cfg.loans,
+		slots:       make(chan struct{}, concurrency),
+	}
+}
+
+// seriesKey identifies the metrics exported for one maturity of one source
+type seriesKey struct {
+	source   string
+	maturity string
+}
+
+// activeSeries returns the series polled with these settings
+func (s exporterSettings) activeSeries() map[seriesKey]bool {
+	active := make(map[seriesKey]bool)
+	for _, src := range s.sources.Sources() {
+		for _, m := range src.Maturities() {
+			if s.maturities[m] {
+				active[seriesKey{source: src.Name(), maturity: m}] = true
+			}
+		}
+	}
+	return active
+}
+
+// sourceOptions returns the options of the named source, filling in defaults
+func (s exporterSettings) sourceOptions(name string) sourceOptions {
+	opts := s.options[name]
+	if opts.interval <= 0 {
+		opts.interval = defaultInterval
+	}
+	if opts.timeout <= 0 {
+		opts.timeout = defaultTimeout
+	}
+	return opts
+}
+
+// maturityList returns the enabled maturities in sorted order
+func (s exporterSettings) maturityList() []string {
+	list := make([]string, 0, len(s.maturities))
+	for m := range s.maturities {
+		list = append(list, m)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// current returns a snapshot of the exporter settings
+func (e *EuriborExporter) current() exporterSettings {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.settings
+}
+
+// Reload replaces the sources, options and maturities of a running exporter.
+// Only the pollers of sources that were removed or whose interval or
+// schedule changed are stopped or restarted; the others pick up the new
+// settings on their next poll. Series that are no longer polled are removed;
+// all others keep their values.
+func (e *EuriborExporter) Reload(cfg exporterConfig) {
+	next := newExporterSettings(cfg)
+
+	e.mu.Lock()
+	prev := e.settings
+	if next.concurrency == prev.concurrency {
+		next.slots = prev.slots // Keep fetches in flight counted against the limit
+	}
+	e.settings = next
+	e.mu.Unlock()
+
+	active := next.activeSeries()
+	for series := range prev.activeSeries() {
+		if !active[series] {
+			e.snapshots.delete(series)
+			log.WithFields(logrus.Fields{
+				"source":   series.source,
+				"maturity": series.maturity,
+			}).Info("Removed metrics for series no longer polled")
+		}
+	}
+
+	// A new TTL can flip the stale flag of any series
+	if next.seriesTTL != prev.seriesTTL {
+		e.snapshots.markChanged(time.Now())
+	}
+
+	// Non-blocking: a pending reload already picks up the latest settings
+	select {
+	case e.reloadCh <- struct{}{}:
+	default:
+	}
+}
+
+// updateSource fetches every enabled maturity supported by src concurrently.
+// Each fetch waits for a free slot in slots, which bounds parallelism across
+// all sources sharing it, and then gets at most timeout to complete. It
+// returns the oldest publication date among the successful fetches, or the
+// zero time if none succeeded.
+func (e *EuriborExporter) updateSource(ctx context.Context, src source.RateSource, timeout time.Duration, maturities map[string]bool, slots chan struct{}) time.Time {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		oldest time.Time
+	)
+
+	for _, maturity := range src.Maturities() {
+		if !maturities[maturity] {
+			continue
+		}
+
+		// Stop early instead of starting fetches that would fail immediately
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return oldest
+		}
+
+		wg.Add(1)
+		go func(maturity string) {
+			defer wg.Done()
+
+			e.fetchesInFlight.Inc()
+			fetchCtx, cancel := context.WithTimeoutCause(ctx, timeout, errFetchDeadline)
+			rate, newMonth := e.updateSourceMetrics(fetchCtx, src, maturity)
+			cancel()
+			e.fetchesInFlight.Dec()
+			<-slots
+
+			if rate == nil {
+				return
+			}
+			mu.Lock()
+			if oldest.IsZero() || rate.PublicationDate.Before(oldest) {
+				oldest = rate.PublicationDate
+			}
+			mu.Unlock()
+
+			// The other sources are filled after releasing the slot, each
+			// taking its own, so a small pool cannot deadlock on them
+			if newMonth {
+				e.fillMonthFromPeers(ctx, src.Name(), maturity, rate.PublicationDate, slots)
+			}
+		}(maturity)
+	}
+
+	wg.Wait()
+	return oldest
+}
+
+// updateSourceMetrics fetches a single maturity from src and updates its
+// metrics. It returns the fetched rate, or nil if the fetch failed, and
+// whether it is the first rate of its month.
+func (e *EuriborExporter) updateSourceMetrics(ctx context.Context, src source.RateSource, maturity string) (*source.Rate, bool) {
+	name := src.Name()
+	series := seriesKey{source: name, maturity: maturity}
+
+	ctx = retry.WithObserver(ctx, func(attempt int, reason string, delay time.Duration) {
+		e.fetchRetries.WithLabelValues(name, maturity).Inc()
+		log.WithFields(logrus.Fields{
+			"maturity": maturity,
+			"source":   name,
+			"attempt":  attempt,
+			"reason":   reason,
+			"delay":    delay,
+		}).Warn("Retrying Euribor fetch")
+	})
+
+	startTime := time.Now()
+
+	rate, err := src.Fetch(ctx, maturity)
+	endTime := time.Now()
+	duration := endTime.Sub(startTime).Seconds()
+
+	if err != nil {
+		fields := logrus.Fields{
+			"maturity": maturity,
+			"source":   name,
+			"error":    err,
+		}
+
+		if ctx.Err() != nil {
+			reason := cancelReason(ctx)
+			fields["cancel_reason"] = reason
+			e.fetchCancellations.WithLabelValues(name, maturity, reason).Inc()
+
+			// Aborting on purpose is not a fetch failure worth an error
+			if reason == "shutdown" || reason == "reload" {
+				log.WithFields(fields).Warn("Fetch cancelled")
+				return nil, false
+			}
+		}
+
+		if errors.Is(err, breaker.ErrOpen) {
+			log.WithFields(fields).Warn("Skipped fetch, circuit breaker open")
+		} else {
+			reason := fetcherr.ReasonOf(err)
+			fields["reason"] = reason
+			e.fetchErrors.WithLabelValues(name, maturity, string(reason)).Inc()
+			log.WithFields(fields).Error("Failed to fetch Euribor rate")
+			if reason == fetcherr.ParseHTML {
+				e.snapshots.clearStrategy(series)
+			}
+		}
+		e.snapshots.recordFailure(series, startTime, endTime)
+		return nil, false
+	}
+
+	settings := e.current()
+	previous, _ := e.snapshots.accepted(series)
+	peers := e.snapshots.peers(maturity, name, endTime, settings.seriesTTL)
+	if r := settings.validation.validate(*rate, previous, peers); r != nil {
+		e.validationRejections.WithLabelValues(name, maturity, r.rule).Inc()
+		log.WithFields(logrus.Fields{
+			"maturity": maturity,
+			"source":   name,
+			"rate":     rate.Rate,
+			"pub_date": rate.PublicationDate.Format("2006-01-02"),
+			"rule":     r.rule,
+			"reason":   r.detail,
+		}).Warn("Rejected implausible Euribor rate, keeping previous value")
+		e.snapshots.recordFailure(series, startTime, endTime)
+		return nil, false
+	}
+
+	e.snapshots.recordSuccess(series, rate, startTime, endTime)
+	newMonth := e.reconciler.observe(maturity, *rate)
+	e.recordHistory(name, maturity, rate, endTime)
+	if newMonth {
+		e.fillMonth(ctx, src, maturity, rate.PublicationDate)
+	}
+	if missedFixings(previous, *rate) {
+		var since time.Time
+		if previous != nil {
+			since = previous.PublicationDate
+		}
+		e.fillGap(ctx, src, maturity, since, rate.PublicationDate)
+	}
+	e.saveState()
+
+	log.WithFields(logrus.Fields{
+		"maturity": maturity,
+		"source":   name,
+		"rate":     rate.Rate,
+		"pub_date": rate.PublicationDate.Format("2006-01-02"),
+		"duration": duration,
+	}).Info("Updated Euribor metric")
+
+	return rate, newMonth
+}
+
+// pollState is what the poller of a source has seen. It outlives the poller,
+// so a restart after a reload neither polls at once nor forgets the schedule.
+type pollState struct {
+	lastPoll   time.Time // When the last update finished
+	newest     time.Time // Newest publication date seen so far
+	lastChange time.Time // When newest last advanced
+}
+
+// observe records the publication date returned by a poll at now. The first
+// result is only a baseline, as it may be yesterday's fixing; later ones
+// that are newer count as a change.
+func (st pollState) observe(pubDate, now time.Time) pollState {
+	if pubDate.After(st.newest) {
+		if !st.newest.IsZero() {
+			st.lastChange = now
+		}
+		st.newest = pubDate
+	}
+	return st
+}
+
+func (e *EuriborExporter) pollState(name string) pollState {
+	e.pollMu.Lock()
+	defer e.pollMu.Unlock()
+	return e.pollStates[name]
+}
+
+func (e *EuriborExporter) setPollState(name string, st pollState) {
+	e.pollMu.Lock()
+	defer e.pollMu.Unlock()
+	e.pollStates[name] = st
+}
+
+// poller is the polling goroutine of one source
+type poller struct {
+	opts   sourceOptions
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// Run polls each source on its own interval or schedule until ctx is
+// cancelled. Pollers are started, stopped and restarted as Reload changes
+// the sources.
+func (e *EuriborExporter) Run(ctx context.Context) {
+	pollers := make(map[string]*poller)
+	for {
+		e.syncPollers(ctx, pollers, e.current())
+
+		select {
+		case <-e.reloadCh:
+		case <-ctx.Done():
+			for _, p := range pollers {
+				<-p.done
+			}
+			log.WithField("reason", context.Cause(ctx)).Info("Stopping exporter")
+			return
+		}
+	}
+}
+
+// syncPollers stops the pollers of sources missing from settings, restarts
+// those whose interval or schedule changed and starts one for every new
+// source
+func (e *EuriborExporter) syncPollers(ctx context.Context, pollers map[string]*poller, settings exporterSettings) {
+	for name, p := range pollers {
+		_, exists := settings.sources.Get(name)
+		if exists && p.opts.sameSchedule(settings.sourceOptions(name)) {
+			continue
+		}
+
+		p.cancel(errReload)
+		<-p.done
+		delete(pollers, name)
+
+		if !exists {
+			e.pollMu.Lock()
+			delete(e.pollStates, name)
+			e.pollMu.Unlock()
+			log.WithField("source", name).Info("Stopped polling removed source")
+		} else {
+			log.WithField("source", name).Info("Restarting poller with reloaded schedule")
+		}
+	}
+
+	for _, name := range settings.sources.Names() {
+		if _, running := pollers[name]; running {
+			continue
+		}
+
+		pollCtx, cancel := context.WithCancelCause(ctx)
+		p := &poller{
+			opts:   settings.sourceOptions(name),
+			cancel: cancel,
+			done:   make(chan struct{}),
+		}
+		pollers[name] = p
+
+		go func(name string) {
+			defer close(p.done)
+			e.runSource(pollCtx, name, p.opts)
+		}(name)
+	}
+}
+
+// sameSchedule reports whether o polls at the same times as other
+func (o sourceOptions) sameSchedule(other sourceOptions) bool {
+	if o.interval != other.interval {
+		return false
+	}
+	if o.schedule == nil || other.schedule == nil {
+		return o.schedule == other.schedule
+	}
+	return o.schedule.equal(other.schedule)
+}
+
+// pollSource fetches every enabled maturity of the named source with the
+// current settings. It returns the same as updateSource, or the zero time if
+// the source is no longer registered.
+func (e *EuriborExporter) pollSource(ctx context.Context, name string) time.Time {
+	settings := e.current()
+	src, exists := settings.sources.Get(name)
+	if !exists {
+		return time.Time{}
+	}
+	return e.updateSource(ctx, src, settings.sourceOptions(name).timeout, settings.maturities, settings.slots)
+}
+
+// runSource polls the named source until ctx is cancelled. The first poll
+// happens at once only if the source has not been polled before.
+func (e *EuriborExporter) runSource(ctx context.Context, name string, opts sourceOptions) {
+	if opts.schedule != nil {
+		e.runScheduledSource(ctx, name, opts)
+		return
+	}
+
+	fields := logrus.Fields{
+		"source":   name,
+		"interval": opts.interval,
+	}
+
+	st := e.pollState(name)
+	for {
+		if !st.lastPoll.IsZero() {
+			timer := time.NewTimer(time.Until(st.lastPoll.Add(opts.interval)))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+			log.WithFields(fields).Info("Performing scheduled metrics update")
+		} else {
+			log.WithFields(fields).Info("Performing initial metrics update")
+		}
+
+		e.pollSource(ctx, name)
+		if ctx.Err() != nil {
+			return
+		}
+		st.lastPoll = time.Now()
+		e.setPollState(name, st)
+	}
+}
+
+// runScheduledSource polls the named source on its publication-aware
+// schedule until ctx is cancelled
+func (e *EuriborExporter) runScheduledSource(ctx context.Context, name string, opts sourceOptions) {
+	fields := logrus.Fields{
+		"source":   name,
+		"schedule": "calendar",
+	}
+
+	update := func(st pollState) pollState {
+		pubDate := e.pollSource(ctx, name)
+		if ctx.Err() != nil {
+			return st
+		}
+
+		st = st.observe(pubDate, time.Now())
+		st.lastPoll = time.Now()
+		e.setPollState(name, st)
+
+		settings := e.current()
+		if src, exists := settings.sources.Get(name); exists {
+			next := opts.schedule.publication.Next(time.Now())
+			for _, maturity := range src.Maturities() {
+				if settings.maturities[maturity] {
+					e.snapshots.setExpectedPublication(maturity, next)
+				}
+			}
+		}
+		return st
+	}
+
+	st := e.pollState(name)
+	if st.lastPoll.IsZero() {
+		log.WithFields(fields).Info("Performing initial metrics update")
+		st = update(st)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+
+	for {
+		// Scheduled from the last poll, which may predate a reload
+		due := st.lastPoll.Add(opts.schedule.nextPoll(st.lastPoll, st.lastChange, opts.interval))
+		log.WithFields(fields).WithField("next_poll", due.Format(time.RFC3339)).Debug("Scheduled next metrics update")
+
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-timer.C:
+			log.WithFields(fields).Info("Performing scheduled metrics update")
+			st = update(st)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// cancelReason maps the cause of a cancelled fetch context to a metric label
+func cancelReason(ctx context.Context) string {
+	cause := context.Cause(ctx)
+	switch {
+	case errors.Is(cause, errShutdown):
+		return "shutdown"
+	case errors.Is(cause, errReload):
+		return "reload"
+	case errors.Is(cause, errFetchDeadline), errors.Is(cause, context.DeadlineExceeded):
+		return "deadline"
+	default:
+		return "canceled"
+	}
+}
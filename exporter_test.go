@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeSource returns fixed rates and fails for maturities without one
+type fakeSource struct {
+	name     string
+	rates    map[string]float64
+	strategy string // Reported as Rate.Strategy
+	monthly  bool   // Report monthly averages instead of daily fixings
+	err      error  // Returned instead of the 503 for missing rates
+	fetches  atomic.Int64
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Maturities() []string {
+	return []string{"3M", "12M"}
+}
+
+func (f *fakeSource) Fetch(ctx context.Context, maturity string) (*source.Rate, error) {
+	f.fetches.Add(1)
+	rate, exists := f.rates[maturity]
+	if !exists {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, fetcherr.Status(http.StatusServiceUnavailable)
+	}
+	frequency := source.Daily
+	if f.monthly {
+		frequency = source.Monthly
+	}
+	return &source.Rate{
+		Rate:            rate,
+		PublicationDate: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+		Frequency:       frequency,
+		Strategy:        f.strategy,
+	}, nil
+}
+
+func newTestExporter(t *testing.T, src source.RateSource, ttl time.Duration, legacyNames bool) (*EuriborExporter, *prometheus.Registry) {
+	t.Helper()
+	log.SetLevel(logrus.PanicLevel)
+
+	sources := source.NewRegistry()
+	sources.MustRegister(src)
+
+	exporter := NewEuriborExporter(exporterConfig{
+		sources:     sources,
+		maturities:  []string{"3M", "12M"},
+		concurrency: 2,
+		seriesTTL:   ttl,
+		legacyNames: legacyNames,
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter)
+
+	return exporter, registry
+}
+
+// poll runs one update of every source, as the pollers do on startup
+func poll(e *EuriborExporter) {
+	for _, name := range e.current().sources.Names() {
+		e.pollSource(context.Background(), name)
+	}
+}
+
+func TestExporterCollectsSnapshots(t *testing.T) {
+	exporter, registry := newTestExporter(t, &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081},
+	}, time.Hour, false)
+
+	poll(exporter)
+
+	want := `
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="fake"} 2.081
+# HELP euribor_fetch_errors_total Failed fetches by reason (network, timeout, http_status, parse_html, parse_response, parse_rate, parse_date, empty_series, unknown)
+# TYPE euribor_fetch_errors_total counter
+euribor_fetch_errors_total{maturity="12M",reason="http_status",source="fake"} 1
+# HELP euribor_fetch_success Whether the last fetch was successful (1 = success, 0 = failure)
+# TYPE euribor_fetch_success gauge
+euribor_fetch_success{maturity="12M",source="fake"} 0
+euribor_fetch_success{maturity="3M",source="fake"} 1
+# HELP euribor_series_stale Whether the series has not been fetched successfully within the series TTL and its rate is no longer exported (1 = stale)
+# TYPE euribor_series_stale gauge
+euribor_series_stale{maturity="3M",source="fake"} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_rate_percent", "euribor_fetch_errors_total", "euribor_fetch_success", "euribor_series_stale")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Legacy names are opt-in
+	if n := testutil.CollectAndCount(exporter, "euribor_scrape_success"); n != 0 {
+		t.Errorf("exported %d legacy success series, want 0", n)
+	}
+
+	// Failed fetches are attempts but not successes
+	if n := testutil.CollectAndCount(exporter, "euribor_last_attempt_timestamp_seconds"); n != 2 {
+		t.Errorf("exported %d last attempt series, want 2", n)
+	}
+	if n := testutil.CollectAndCount(exporter, "euribor_last_success_timestamp_seconds"); n != 1 {
+		t.Errorf("exported %d last success series, want 1", n)
+	}
+}
+
+func TestExporterRejectsImplausibleRate(t *testing.T) {
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081},
+	}
+	exporter, registry := newTestExporter(t, src, time.Hour, false)
+	exporter.Reload(exporterConfig{
+		sources:     exporter.current().sources,
+		maturities:  []string{"3M", "12M"},
+		concurrency: 2,
+		seriesTTL:   time.Hour,
+		validation:  validationPolicy{minRate: -2, maxRate: 10},
+	})
+
+	poll(exporter)
+
+	// A misparsed rate keeps the previous good value
+	src.rates["3M"] = 20.81
+	poll(exporter)
+
+	want := `
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="fake"} 2.081
+# HELP euribor_validation_rejections_total Fetched rates rejected as implausible, by rule (bounds, jump, divergence)
+# TYPE euribor_validation_rejections_total counter
+euribor_validation_rejections_total{maturity="3M",rule="bounds",source="fake"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_rate_percent", "euribor_validation_rejections_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExporterDropsStaleSeries(t *testing.T) {
+	exporter, registry := newTestExporter(t, &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081, "12M": 2.264},
+	}, time.Hour, false)
+
+	poll(exporter)
+
+	// Age the 12M series past the TTL
+	key := seriesKey{source: "fake", maturity: "12M"}
+	exporter.snapshots.mu.Lock()
+	snap := exporter.snapshots.series[key]
+	snap.lastSuccess = time.Now().Add(-2 * time.Hour)
+	exporter.snapshots.series[key] = snap
+	exporter.snapshots.mu.Unlock()
+
+	want := `
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="fake"} 2.081
+# HELP euribor_series_stale Whether the series has not been fetched successfully within the series TTL and its rate is no longer exported (1 = stale)
+# TYPE euribor_series_stale gauge
+euribor_series_stale{maturity="12M",source="fake"} 1
+euribor_series_stale{maturity="3M",source="fake"} 0
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_rate_percent", "euribor_series_stale")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExporterReloadRemovesSeries(t *testing.T) {
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081, "12M": 2.264},
+	}
+	exporter, registry := newTestExporter(t, src, 0, true)
+
+	poll(exporter)
+
+	sources := source.NewRegistry()
+	sources.MustRegister(src)
+	exporter.Reload(exporterConfig{
+		sources:     sources,
+		maturities:  []string{"3M"},
+		concurrency: 2,
+		legacyNames: true,
+	})
+
+	for _, name := range []string{"euribor_rate_percent", "euribor_fetch_success"} {
+		if n := testutil.CollectAndCount(exporter, name); n != 1 {
+			t.Errorf("exported %d %s series after reload, want 1", n, name)
+		}
+	}
+
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) > 0 {
+		t.Errorf("GatherAndLint() = %v, %v", problems, err)
+	}
+}
+
+func TestExporterLegacyNames(t *testing.T) {
+	exporter, registry := newTestExporter(t, &fakeSource{
+		name:  sourceECB,
+		rates: map[string]float64{"3M": 2.081},
+	}, time.Hour, true)
+
+	poll(exporter)
+
+	// The old ECB euribor_rate_percent{maturity} gives way to the unified name
+	want := `
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="ecb"} 2.081
+# HELP euribor_scrape_success Whether the last scrape was successful (1 = success, 0 = failure)
+# TYPE euribor_scrape_success gauge
+euribor_scrape_success{maturity="12M"} 0
+euribor_scrape_success{maturity="3M"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_rate_percent", "euribor_scrape_success")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// The per-source names of unreleased versions are not kept
+	if n := testutil.CollectAndCount(exporter, "euribor_source_rate_percent"); n != 0 {
+		t.Errorf("exported %d euribor_source_rate_percent series, want 0", n)
+	}
+
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) > 0 {
+		t.Errorf("GatherAndLint() = %v, %v", problems, err)
+	}
+}
+
+func TestExporterReportsMissedPublicationsWhenStale(t *testing.T) {
+	publication, err := calendar.ParsePublication("11:00", calendar.DefaultLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter, _ := newTestExporter(t, &fakeSource{
+		name:  sourceDaily,
+		rates: map[string]float64{"3M": 2.081},
+	}, time.Hour, false)
+	exporter.Reload(exporterConfig{
+		sources:     exporter.current().sources,
+		maturities:  []string{"3M", "12M"},
+		concurrency: 2,
+		calendar:    publicationCalendar{publication: publication, lag: 1},
+		seriesTTL:   time.Hour,
+	})
+
+	poll(exporter)
+
+	key := seriesKey{source: sourceDaily, maturity: "3M"}
+	exporter.snapshots.mu.Lock()
+	snap := exporter.snapshots.series[key]
+	snap.lastSuccess = time.Now().Add(-2 * time.Hour)
+	exporter.snapshots.series[key] = snap
+	exporter.snapshots.mu.Unlock()
+
+	// The rate is dropped but the data age stays visible
+	if n := testutil.CollectAndCount(exporter, "euribor_rate_percent"); n != 0 {
+		t.Errorf("exported %d rate series past the TTL, want 0", n)
+	}
+	if n := testutil.CollectAndCount(exporter, "euribor_daily_publications_missed"); n != 1 {
+		t.Errorf("exported %d missed publications series past the TTL, want 1", n)
+	}
+}
+
+func TestExporterScraperStrategy(t *testing.T) {
+	publication, err := calendar.ParsePublication("11:00", calendar.DefaultLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &fakeSource{
+		name:     sourceDaily,
+		rates:    map[string]float64{"3M": 2.081, "12M": 2.264},
+		strategy: "header_match",
+		err:      fetcherr.New(fetcherr.ParseHTML, errors.New("rate table not found")),
+	}
+	exporter, registry := newTestExporter(t, src, time.Hour, false)
+	cfg := exporterConfig{
+		sources:     exporter.current().sources,
+		maturities:  []string{"3M", "12M"},
+		concurrency: 2,
+		calendar:    publicationCalendar{publication: publication, lag: 1},
+		seriesTTL:   time.Hour,
+	}
+	exporter.Reload(cfg)
+	poll(exporter)
+
+	// The 12M page stops parsing and 3M is no longer polled
+	delete(src.rates, "12M")
+	poll(exporter)
+	cfg.maturities = []string{"12M"}
+	exporter.Reload(cfg)
+
+	want := `
+# HELP euribor_scraper_strategy_used Extraction strategy that found the rate table in the last daily scrape (1 = used); all 0 after a layout check failed
+# TYPE euribor_scraper_strategy_used gauge
+euribor_scraper_strategy_used{maturity="12M",strategy="header_match"} 0
+euribor_scraper_strategy_used{maturity="12M",strategy="table_historiek"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "euribor_scraper_strategy_used"); err != nil {
+		t.Error(err)
+	}
+}
+
+// waitFetches waits until src has been fetched at least n times
+func waitFetches(t *testing.T, src *fakeSource, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for src.fetches.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s fetched %d times, want at least %d", src.name, src.fetches.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunReloadRestartsOnlyChangedPollers(t *testing.T) {
+	log.SetLevel(logrus.PanicLevel)
+
+	steady := &fakeSource{name: "steady", rates: map[string]float64{"3M": 2.081}}
+	changed := &fakeSource{name: "changed", rates: map[string]float64{"3M": 2.081}}
+	added := &fakeSource{name: "added", rates: map[string]float64{"3M": 2.081}}
+
+	registry := func(sources ...source.RateSource) *source.Registry {
+		r := source.NewRegistry()
+		for _, src := range sources {
+			r.MustRegister(src)
+		}
+		return r
+	}
+
+	cfg := exporterConfig{
+		sources: registry(steady, changed),
+		options: map[string]sourceOptions{
+			"steady":  {interval: time.Hour},
+			"changed": {interval: time.Hour},
+		},
+		maturities:  []string{"3M"},
+		concurrency: 2,
+	}
+	exporter := NewEuriborExporter(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		exporter.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFetches(t, steady, 1)
+	waitFetches(t, changed, 1)
+
+	// A reload with the same schedule for steady, a shorter interval for
+	// changed and a new source only fetches the new source at once
+	cfg.sources = registry(steady, changed, added)
+	cfg.options = map[string]sourceOptions{
+		"steady":  {interval: time.Hour},
+		"changed": {interval: 300 * time.Millisecond},
+		"added":   {interval: time.Hour},
+	}
+	exporter.Reload(cfg)
+
+	waitFetches(t, added, 1)
+	if n := changed.fetches.Load(); n != 1 {
+		t.Errorf("changed fetched %d times right after the reload, want 1", n)
+	}
+
+	// The restarted poller follows its new interval from its last poll
+	waitFetches(t, changed, 2)
+	if n := steady.fetches.Load(); n != 1 {
+		t.Errorf("steady fetched %d times, want 1: its poller was restarted", n)
+	}
+}
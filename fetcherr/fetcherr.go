@@ -0,0 +1,82 @@
+package fetcherr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Reason classifies why fetching a rate from upstream failed
+type Reason string
+
+const (
+	Network       Reason = "network"        // Connection or DNS failure
+	Timeout       Reason = "timeout"        // Request deadline or client timeout
+	HTTPStatus    Reason = "http_status"    // Unexpected HTTP status code
+	ParseHTML     Reason = "parse_html"     // Page could not be parsed or has an unknown layout
+	ParseResponse Reason = "parse_response" // API message could not be decoded
+	ParseRate     Reason = "parse_rate"     // Rate value could not be parsed
+	ParseDate     Reason = "parse_date"     // Publication date could not be parsed
+	EmptySeries   Reason = "empty_series"   // Upstream returned no observations
+	Unknown       Reason = "unknown"        // Any error not classified by the fetcher
+)
+
+// Error is a fetch failure with its Reason
+type Error struct {
+	Reason Reason
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New wraps err with reason
+func New(reason Reason, err error) error {
+	return &Error{Reason: reason, Err: err}
+}
+
+// Errorf formats an error like fmt.Errorf and wraps it with reason
+func Errorf(reason Reason, format string, args ...any) error {
+	return New(reason, fmt.Errorf(format, args...))
+}
+
+// StatusError is returned for unexpected HTTP status codes
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP error: %d", e.StatusCode)
+}
+
+// Status returns an HTTPStatus error for code
+func Status(code int) error {
+	return New(HTTPStatus, &StatusError{StatusCode: code})
+}
+
+// Request wraps an error returned by http.Client.Do for a request made with
+// ctx as Timeout or Network
+func Request(ctx context.Context, err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return New(Timeout, err)
+	}
+	return New(Network, err)
+}
+
+// ReasonOf returns the Reason of the outermost Error in err's chain, or
+// Unknown if there is none
+func ReasonOf(err error) Reason {
+	var fetchErr *Error
+	if errors.As(err, &fetchErr) {
+		return fetchErr.Reason
+	}
+	return Unknown
+}
@@ -0,0 +1,65 @@
+package fetcherr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReasonOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Reason
+	}{
+		{"plain error", errors.New("boom"), Unknown},
+		{"typed", Errorf(ParseRate, "bad rate %q", "n/a"), ParseRate},
+		{"wrapped", fmt.Errorf("fetch 3M: %w", Errorf(ParseDate, "bad date")), ParseDate},
+		{"status", Status(http.StatusForbidden), HTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReasonOf(tt.err); got != tt.want {
+				t.Errorf("ReasonOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var statusErr *StatusError
+	if !errors.As(Status(http.StatusForbidden), &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Status() does not unwrap to a StatusError with code 403")
+	}
+}
+
+func TestRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err := http.DefaultClient.Do(req)
+	if got := ReasonOf(Request(ctx, err)); got != Timeout {
+		t.Errorf("deadline exceeded classified as %q, want %q", got, Timeout)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Millisecond}
+	req, _ = http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err = client.Do(req)
+	if got := ReasonOf(Request(context.Background(), err)); got != Timeout {
+		t.Errorf("client timeout classified as %q, want %q", got, Timeout)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "http://127.0.0.1:1", nil)
+	_, err = http.DefaultClient.Do(req)
+	if got := ReasonOf(Request(context.Background(), err)); got != Network {
+		t.Errorf("refused connection classified as %q, want %q", got, Network)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/sirupsen/logrus"
+)
+
+// missedFixings reports whether fixings may have been published between the
+// previous accepted rate of a series and rate, which is always the case for
+// the first rate since the exporter started without state
+func missedFixings(previous *source.Rate, rate source.Rate) bool {
+	return previous == nil || calendar.BusinessDaysBetween(previous.PublicationDate, rate.PublicationDate) > 1
+}
+
+// fillMonth completes the daily fixings of maturity in the month of date from
+// src, so that the first comparison with a newly published monthly average
+// covers the days the exporter was down
+func (e *EuriborExporter) fillMonth(ctx context.Context, src source.RateSource, maturity string, date time.Time) {
+	if e.reconciler.coverage(maturity, period(date)) >= 1 {
+		return
+	}
+
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	e.fillGap(ctx, src, maturity, first.AddDate(0, 0, -1), first.AddDate(0, 1, 0))
+}
+
+// fillMonthFromPeers runs fillMonth for every source offering a history of
+// maturity other than the one named trigger, which filled its own during its
+// fetch. Each fill waits for a free slot in slots and gets the timeout of
+// its own source rather than the remainder of the triggering fetch.
+func (e *EuriborExporter) fillMonthFromPeers(ctx context.Context, trigger, maturity string, date time.Time, slots chan struct{}) {
+	settings := e.current()
+	for _, src := range settings.sources.Sources() {
+		if _, ok := src.(source.HistorySource); !ok || src.Name() == trigger || !slices.Contains(src.Maturities(), maturity) {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		e.fetchesInFlight.Inc()
+		fillCtx, cancel := context.WithTimeoutCause(ctx, settings.sourceOptions(src.Name()).timeout, errFetchDeadline)
+		e.fillMonth(fillCtx, src, maturity, date)
+		cancel()
+		e.fetchesInFlight.Dec()
+		<-slots
+	}
+}
+
+// fillGap fetches the recent history of maturity from src, if src offers one,
+// and records the rates published after since and before latest (a zero since
+// takes all of them) in the rate history and the reconciler. This is how
+// fixings published while the exporter was down are not lost.
+func (e *EuriborExporter) fillGap(ctx context.Context, src source.RateSource, maturity string, since, latest time.Time) {
+	hs, ok := src.(source.HistorySource)
+	if !ok {
+		return
+	}
+
+	fields := logrus.Fields{
+		"maturity": maturity,
+		"source":   src.Name(),
+	}
+
+	rates, err := hs.FetchHistory(ctx, maturity)
+	if err != nil {
+		log.WithFields(fields).WithError(err).Warn("Failed to fetch history to fill missed fixings")
+		return
+	}
+
+	// Only the bounds apply: the jump and divergence checks need neighbours
+	// in publication order, which a backwards fill does not have
+	settings := e.current()
+	bounds := validationPolicy{minRate: settings.validation.minRate, maxRate: settings.validation.maxRate}
+
+	now := time.Now()
+	filled := 0
+	for _, rate := range rates {
+		if !rate.PublicationDate.After(since) || !rate.PublicationDate.Before(latest) {
+			continue
+		}
+		if r := bounds.validate(rate, nil, nil); r != nil {
+			e.validationRejections.WithLabelValues(src.Name(), maturity, r.rule).Inc()
+			continue
+		}
+		e.reconciler.observe(maturity, rate)
+		e.recordHistory(src.Name(), maturity, &rate, now)
+		filled++
+	}
+
+	if filled > 0 {
+		fields["fixings"] = filled
+		log.WithFields(fields).Info("Filled missed fixings from history")
+	}
+}
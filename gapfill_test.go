@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/history"
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+// fakeHistorySource is a fakeSource that also serves a fixed history
+type fakeHistorySource struct {
+	fakeSource
+	history []source.Rate
+	calls   int
+	onFetch func(ctx context.Context) // Called by FetchHistory if set
+}
+
+func (f *fakeHistorySource) FetchHistory(ctx context.Context, maturity string) ([]source.Rate, error) {
+	f.calls++
+	if f.onFetch != nil {
+		f.onFetch(ctx)
+	}
+	return f.history, nil
+}
+
+func TestExporterFillsMissedFixings(t *testing.T) {
+	fixing := func(day int, rate float64) source.Rate {
+		return source.Rate{
+			Rate:            rate,
+			PublicationDate: time.Date(2025, 12, day, 0, 0, 0, 0, time.UTC),
+			Frequency:       source.Daily,
+		}
+	}
+
+	src := &fakeHistorySource{
+		fakeSource: fakeSource{name: sourceDaily, rates: map[string]float64{"3M": 2.081}},
+		history: []source.Rate{
+			fixing(15, 2.081), // The latest fixing, fetched anyway
+			fixing(12, 2.079),
+			fixing(11, 20.77), // Misparsed
+			fixing(10, 2.075),
+		},
+	}
+	exporter, _ := newTestExporter(t, src, time.Hour, false)
+	exporter.Reload(exporterConfig{
+		sources:     exporter.current().sources,
+		maturities:  []string{"3M"},
+		concurrency: 2,
+		seriesTTL:   time.Hour,
+		validation:  validationPolicy{minRate: -2, maxRate: 10},
+	})
+
+	// The first rate since start may follow missed fixings
+	poll(exporter)
+	// The same publication again has no gap
+	poll(exporter)
+
+	if src.calls != 1 {
+		t.Errorf("FetchHistory() called %d times, want 1", src.calls)
+	}
+
+	got := exporter.history.Query(history.Query{Maturity: "3M"})
+	want := []float64{2.075, 2.079, 2.081}
+	if len(got) != len(want) {
+		t.Fatalf("history holds %+v, want rates %v", got, want)
+	}
+	for i, o := range got {
+		if o.Rate != want[i] {
+			t.Errorf("history[%d].Rate = %v, want %v", i, o.Rate, want[i])
+		}
+	}
+
+	if n := len(exporter.reconciler.observations()); n != 3 {
+		t.Errorf("reconciler holds %d observations, want 3", n)
+	}
+}
+
+func TestExporterFillsMonthBeforeReconciling(t *testing.T) {
+	daily := &fakeHistorySource{
+		fakeSource: fakeSource{name: sourceDaily}, // Down for now
+		history: []source.Rate{
+			{Rate: 2.08, PublicationDate: time.Date(2025, 12, 12, 0, 0, 0, 0, time.UTC), Frequency: source.Daily},
+			{Rate: 2.06, PublicationDate: time.Date(2025, 12, 11, 0, 0, 0, 0, time.UTC), Frequency: source.Daily},
+			{Rate: 2.03, PublicationDate: time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC), Frequency: source.Daily},
+		},
+	}
+	monthly := &fakeSource{name: sourceECB, rates: map[string]float64{"3M": 2.05}, monthly: true}
+
+	sources := source.NewRegistry()
+	sources.MustRegister(daily)
+	sources.MustRegister(monthly)
+	exporter := NewEuriborExporter(exporterConfig{
+		sources:     sources,
+		maturities:  []string{"3M"},
+		concurrency: 2,
+	})
+
+	// A new monthly average pulls in the fixings of its month only
+	poll(exporter)
+	poll(exporter)
+
+	if daily.calls != 1 {
+		t.Errorf("FetchHistory() called %d times, want 1", daily.calls)
+	}
+
+	divergences := exporter.reconciler.divergences()
+	if len(divergences) != 1 {
+		t.Fatalf("divergences() = %+v, want one for 2025-12", divergences)
+	}
+	if d := divergences[0]; d.period != "2025-12" || math.Abs(d.bp-2) > 1e-9 {
+		t.Errorf("divergence = %+v, want +2bp in 2025-12", d)
+	}
+}
+
+func TestExporterFillsMonthUnderOwnTimeout(t *testing.T) {
+	var (
+		remaining time.Duration
+		slotsHeld int
+		exporter  *EuriborExporter
+	)
+	daily := &fakeHistorySource{
+		fakeSource: fakeSource{name: sourceDaily}, // Down for now
+		history: []source.Rate{
+			{Rate: 2.08, PublicationDate: time.Date(2025, 12, 12, 0, 0, 0, 0, time.UTC), Frequency: source.Daily},
+		},
+		onFetch: func(ctx context.Context) {
+			deadline, _ := ctx.Deadline()
+			remaining = time.Until(deadline)
+			slotsHeld = len(exporter.current().slots)
+		},
+	}
+	monthly := &fakeSource{name: sourceECB, rates: map[string]float64{"3M": 2.05}, monthly: true}
+
+	sources := source.NewRegistry()
+	sources.MustRegister(daily)
+	sources.MustRegister(monthly)
+	exporter = NewEuriborExporter(exporterConfig{
+		sources: sources,
+		options: map[string]sourceOptions{
+			sourceDaily: {timeout: time.Hour},
+			sourceECB:   {timeout: time.Second},
+		},
+		maturities:  []string{"3M"},
+		concurrency: 1,
+	})
+
+	// With a single slot the fill can only run once the ECB fetch released it
+	exporter.pollSource(context.Background(), sourceECB)
+
+	if daily.calls != 1 {
+		t.Fatalf("FetchHistory() called %d times, want 1", daily.calls)
+	}
+	if remaining < time.Minute {
+		t.Errorf("history fetched with %s left, want the daily source's own timeout", remaining)
+	}
+	if slotsHeld != 1 {
+		t.Errorf("%d fetch slots held during the fill, want 1", slotsHeld)
+	}
+}
@@ -0,0 +1,231 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Observation is a distinct rate published by a source
+type Observation struct {
+	Source          string    `json:"source"`
+	Maturity        string    `json:"maturity"`
+	PublicationDate time.Time `json:"publication_date"`
+	Rate            float64   `json:"rate"`
+	Frequency       string    `json:"frequency,omitempty"`
+	ObservedAt      time.Time `json:"observed_at"` // When the rate was first fetched
+}
+
+// key identifies distinct observations
+type key struct {
+	source, maturity string
+	date             time.Time
+	rate             float64
+}
+
+func (o Observation) key() key {
+	return key{o.Source, o.Maturity, o.PublicationDate.UTC(), o.Rate}
+}
+
+// Store is an append-only set of observations. A store opened on a file
+// appends every new observation to it as a JSON line.
+type Store struct {
+	mu           sync.RWMutex
+	seen         map[key]bool
+	observations []Observation
+	file         *os.File
+	size         int64 // Length of the file up to the last complete line
+	repaired     bool  // Whether Open dropped a partly written last line
+}
+
+// New returns an empty store kept in memory only
+func New() *Store {
+	return &Store{seen: make(map[key]bool)}
+}
+
+// Open loads the observations saved at path, creating the file if needed,
+// and appends new observations to it. A malformed last line, left behind by
+// a write that was cut short, is dropped and truncated away; malformed lines
+// before it are an error.
+func Open(path string) (*Store, error) {
+	s := New()
+
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		err := s.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+
+	if s.repaired {
+		if err := os.Truncate(path, s.size); err != nil {
+			return nil, fmt.Errorf("failed to drop partly written last line of %s: %w", path, err)
+		}
+	}
+
+	s.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads the observations in f and sets s.size to the end of the last
+// complete, well-formed line
+func (s *Store) load(f *os.File) error {
+	r := bufio.NewReader(f)
+
+	var (
+		offset  int64
+		badLine int   // First malformed line, 0 if none
+		badErr  error // Why badLine is malformed
+	)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		offset += int64(len(data))
+		complete := bytes.HasSuffix(data, []byte{'\n'})
+
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			if badLine != 0 {
+				return fmt.Errorf("line %d: %w", badLine, badErr)
+			}
+
+			var o Observation
+			if jsonErr := json.Unmarshal(trimmed, &o); jsonErr != nil || !complete {
+				// Only acceptable if nothing follows
+				badLine, badErr = line, jsonErr
+				if badErr == nil {
+					badErr = io.ErrUnexpectedEOF
+				}
+			} else if k := o.key(); !s.seen[k] {
+				s.seen[k] = true
+				s.observations = append(s.observations, o)
+			}
+		}
+
+		if badLine == 0 && complete {
+			s.size = offset
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	s.repaired = offset != s.size
+	return nil
+}
+
+// Repaired reports whether Open dropped a partly written last line
+func (s *Store) Repaired() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.repaired
+}
+
+// Add records o unless the same source, maturity, publication date and rate
+// is already known. It reports whether o was new.
+func (s *Store) Add(o Observation) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := o.key()
+	if s.seen[k] {
+		return false, nil
+	}
+
+	if s.file != nil {
+		line, err := json.Marshal(o)
+		if err != nil {
+			return false, err
+		}
+		n, err := s.file.Write(append(line, '\n'))
+		if err != nil {
+			// Cut off a partial line so the next one starts on its own line
+			if n > 0 {
+				s.file.Truncate(s.size)
+			}
+			return false, err
+		}
+		s.size += int64(n)
+	}
+
+	s.seen[k] = true
+	s.observations = append(s.observations, o)
+	return true, nil
+}
+
+// Query selects observations. Empty fields match everything; From and To
+// bound the calendar day of the publication date inclusively.
+type Query struct {
+	Source   string
+	Maturity string
+	From, To time.Time
+}
+
+// Query returns the matching observations ordered by publication date, then
+// source and maturity
+func (s *Store) Query(q Query) []Observation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Observation
+	for _, o := range s.observations {
+		switch {
+		case q.Source != "" && o.Source != q.Source,
+			q.Maturity != "" && o.Maturity != q.Maturity,
+			!q.From.IsZero() && day(o.PublicationDate).Before(day(q.From)),
+			!q.To.IsZero() && day(o.PublicationDate).After(day(q.To)):
+			continue
+		}
+		result = append(result, o)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if !a.PublicationDate.Equal(b.PublicationDate) {
+			return a.PublicationDate.Before(b.PublicationDate)
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Maturity < b.Maturity
+	})
+
+	return result
+}
+
+// day drops the time of day and location of t, keeping its calendar day
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Close closes the file of a store opened with Open
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
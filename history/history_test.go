@@ -0,0 +1,206 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func observation(src, maturity string, day int, rate float64) Observation {
+	return Observation{
+		Source:          src,
+		Maturity:        maturity,
+		PublicationDate: time.Date(2025, 12, day, 0, 0, 0, 0, time.UTC),
+		Rate:            rate,
+		Frequency:       "daily",
+		ObservedAt:      time.Date(2025, 12, day, 11, 5, 0, 0, time.UTC),
+	}
+}
+
+func TestStoreAddDeduplicates(t *testing.T) {
+	s := New()
+
+	tests := []struct {
+		name string
+		obs  Observation
+		want bool
+	}{
+		{"first", observation("daily-scraper", "3M", 15, 2.081), true},
+		{"same rate polled again", observation("daily-scraper", "3M", 15, 2.081), false},
+		{"corrected rate", observation("daily-scraper", "3M", 15, 2.082), true},
+		{"other source", observation("ecb", "3M", 15, 2.081), true},
+		{"other maturity", observation("daily-scraper", "12M", 15, 2.081), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Add(tt.obs)
+			if err != nil {
+				t.Fatalf("Add() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if n := len(s.Query(Query{})); n != 4 {
+		t.Errorf("Query() returned %d observations, want 4", n)
+	}
+}
+
+func TestStoreQuery(t *testing.T) {
+	s := New()
+	for _, o := range []Observation{
+		observation("daily-scraper", "3M", 16, 2.079),
+		observation("daily-scraper", "3M", 12, 2.081),
+		observation("ecb", "3M", 12, 2.071),
+		observation("daily-scraper", "12M", 15, 2.264),
+		observation("daily-scraper", "3M", 15, 2.080),
+	} {
+		if _, err := s.Add(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	brussels, _ := time.LoadLocation("Europe/Brussels")
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []float64
+	}{
+		{"all ordered by date", Query{}, []float64{2.081, 2.071, 2.264, 2.080, 2.079}},
+		{"maturity", Query{Maturity: "3M"}, []float64{2.081, 2.071, 2.080, 2.079}},
+		{"source and maturity", Query{Source: "daily-scraper", Maturity: "3M"}, []float64{2.081, 2.080, 2.079}},
+		{
+			name:  "inclusive range",
+			query: Query{Maturity: "3M", From: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 12, 16, 0, 0, 0, 0, time.UTC)},
+			want:  []float64{2.080, 2.079},
+		},
+		{
+			// Days are compared by calendar date, whatever the location
+			name:  "range in other location",
+			query: Query{Maturity: "3M", To: time.Date(2025, 12, 15, 0, 0, 0, 0, brussels)},
+			want:  []float64{2.081, 2.071, 2.080},
+		},
+		{"no match", Query{Maturity: "6M"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Query(tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query() returned %d observations, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, o := range got {
+				if o.Rate != tt.want[i] {
+					t.Errorf("Query()[%d].Rate = %v, want %v", i, o.Rate, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of missing file unexpected error: %v", err)
+	}
+	for _, o := range []Observation{
+		observation("daily-scraper", "3M", 12, 2.081),
+		observation("daily-scraper", "3M", 15, 2.080),
+		observation("daily-scraper", "3M", 15, 2.080),
+	} {
+		if _, err := s.Add(o); err != nil {
+			t.Fatalf("Add() unexpected error: %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() unexpected error: %v", err)
+	}
+	defer reopened.Close()
+
+	got := reopened.Query(Query{})
+	if len(got) != 2 || got[0] != observation("daily-scraper", "3M", 12, 2.081) {
+		t.Errorf("Open() restored %+v, want the 2 distinct observations", got)
+	}
+
+	// Known observations are not appended again
+	if added, _ := reopened.Add(observation("daily-scraper", "3M", 15, 2.080)); added {
+		t.Error("Add() of a restored observation = true, want false")
+	}
+}
+
+func TestOpenTornLastLine(t *testing.T) {
+	good := `{"source":"ecb","maturity":"3M","publication_date":"2025-12-12T00:00:00Z","rate":2.071}` + "\n"
+
+	tests := []struct {
+		name         string
+		content      string
+		want         int // Observations loaded
+		wantRepaired bool
+		wantErr      bool
+	}{
+		{"partial last line", good + `{"source":`, 1, true, false},
+		{"last line without newline", good + strings.TrimSuffix(good, "\n"), 1, true, false},
+		{"zero-filled tail", good + "\x00\x00\x00", 1, true, false},
+		{"malformed last line", good + "{\"source\":}\n", 1, true, false},
+		{"blank lines", good + "\n\n", 1, false, false},
+		{"malformed line in the middle", `{"source":` + "\n" + good, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := Open(path)
+			if tt.wantErr {
+				if err == nil {
+					s.Close()
+					t.Fatal("Open() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() unexpected error: %v", err)
+			}
+			if n := len(s.Query(Query{})); n != tt.want {
+				t.Errorf("Open() loaded %d observations, want %d", n, tt.want)
+			}
+			if got := s.Repaired(); got != tt.wantRepaired {
+				t.Errorf("Repaired() = %v, want %v", got, tt.wantRepaired)
+			}
+
+			// New observations start on a line of their own and survive a reopen
+			if _, err := s.Add(observation("daily-scraper", "3M", 15, 2.080)); err != nil {
+				t.Fatalf("Add() unexpected error: %v", err)
+			}
+			s.Close()
+
+			reopened, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() after Add unexpected error: %v", err)
+			}
+			defer reopened.Close()
+			if n := len(reopened.Query(Query{})); n != tt.want+1 {
+				t.Errorf("reopened store has %d observations, want %d", n, tt.want+1)
+			}
+			if reopened.Repaired() {
+				t.Error("Repaired() = true after reopening a repaired file, want false")
+			}
+		})
+	}
+}
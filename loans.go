@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/config"
+)
+
+// loan is a configured Euribor-linked loan
+type loan struct {
+	name            string
+	principal       float64  // Outstanding principal in EUR
+	margin          float64  // Percentage points added to the reference rate
+	maturity        string   // Euribor maturity the rate is tied to
+	referenceRate   *float64 // Reference rate fixed at the last reset; nil follows the current rate
+	nextReset       time.Time
+	resetMonths     int
+	remainingMonths int
+	linear          bool // Equal principal repayments instead of an annuity
+}
+
+// loanPayments is what euribor_loan_* reports for one loan
+type loanPayments struct {
+	effectiveRate    float64   // Reference rate plus margin in percent
+	payment          float64   // Next monthly payment in EUR
+	nextReset        time.Time // Next reset after now
+	projectedRate    float64   // Effective rate from the next reset at today's reference rate
+	projectedPayment float64   // First monthly payment after the next reset at that rate
+}
+
+func loansFromConfig(cfg []config.Loan) []loan {
+	loans := make([]loan, 0, len(cfg))
+	for _, l := range cfg {
+		reset, _ := l.NextResetDate() // Validated by loadConfig
+		loans = append(loans, loan{
+			name:            l.Name,
+			principal:       l.Principal,
+			margin:          l.Margin,
+			maturity:        l.Maturity,
+			referenceRate:   l.ReferenceRate,
+			nextReset:       reset,
+			resetMonths:     l.ResetMonths,
+			remainingMonths: l.RemainingMonths,
+			linear:          l.Amortization == config.AmortizationLinear,
+		})
+	}
+	return loans
+}
+
+// payments works out the payments of the loan given the current reference
+// rate. The principal is amortized at the current effective rate until the
+// next reset and the rest is then repaid over the remaining term at the
+// current reference rate plus margin.
+func (l loan) payments(reference float64, now time.Time) loanPayments {
+	fixed := reference
+	if l.referenceRate != nil {
+		fixed = *l.referenceRate
+	}
+
+	p := loanPayments{
+		effectiveRate: fixed + l.margin,
+		nextReset:     l.resetAfter(now),
+		projectedRate: reference + l.margin,
+	}
+	p.payment = monthlyPayment(l.principal, p.effectiveRate, l.remainingMonths, l.linear)
+
+	paid := min(monthsBetween(now, p.nextReset), l.remainingMonths-1)
+	balance := balanceAfter(l.principal, p.effectiveRate, l.remainingMonths, paid, l.linear)
+	p.projectedPayment = monthlyPayment(balance, p.projectedRate, l.remainingMonths-paid, l.linear)
+
+	return p
+}
+
+// resetAfter returns the first reset date after now, rolling the configured
+// next reset forward every resetMonths once it has passed
+func (l loan) resetAfter(now time.Time) time.Time {
+	reset := l.nextReset
+	for i := 1; !reset.After(now); i++ {
+		reset = l.nextReset.AddDate(0, i*l.resetMonths, 0)
+	}
+	return reset
+}
+
+// monthsBetween counts the whole months from a to b
+func monthsBetween(a, b time.Time) int {
+	months := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	if b.Day() < a.Day() {
+		months--
+	}
+	return max(0, months)
+}
+
+// monthlyPayment returns the first monthly payment repaying principal over
+// months at the annual rate in percent
+func monthlyPayment(principal, rate float64, months int, linear bool) float64 {
+	i := rate / 100 / 12
+	if linear {
+		return principal/float64(months) + principal*i
+	}
+	if i == 0 {
+		return principal / float64(months)
+	}
+	return principal * i / (1 - math.Pow(1+i, -float64(months)))
+}
+
+// balanceAfter returns the principal left after paid of months payments at
+// the annual rate in percent
+func balanceAfter(principal, rate float64, months, paid int, linear bool) float64 {
+	if linear {
+		return principal * float64(months-paid) / float64(months)
+	}
+	i := rate / 100 / 12
+	payment := monthlyPayment(principal, rate, months, false)
+	if i == 0 {
+		return principal - payment*float64(paid)
+	}
+	growth := math.Pow(1+i, float64(paid))
+	return principal*growth - payment*(growth-1)/i
+}
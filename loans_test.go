@@ -0,0 +1,134 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLoanPayments(t *testing.T) {
+	now := time.Date(2025, 12, 15, 12, 0, 0, 0, time.UTC)
+	fixed := 2.0
+
+	tests := []struct {
+		name          string
+		loan          loan
+		reference     float64
+		wantRate      float64
+		wantPayment   float64
+		wantReset     time.Time
+		wantProjected float64
+	}{
+		{
+			name: "annuity following the current rate",
+			loan: loan{principal: 200000, margin: 1, nextReset: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+				resetMonths: 6, remainingMonths: 240},
+			reference:     2,
+			wantRate:      3,
+			wantPayment:   1109.20,
+			wantReset:     time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+			wantProjected: 1109.20, // An annuity keeps its payment while the rate holds
+		},
+		{
+			name: "annuity resetting to a higher rate",
+			loan: loan{principal: 200000, margin: 1, referenceRate: &fixed,
+				nextReset: time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), resetMonths: 12, remainingMonths: 240},
+			reference:     3,
+			wantRate:      3,
+			wantPayment:   1109.20,
+			wantReset:     time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+			wantProjected: 1209.63,
+		},
+		{
+			name: "linear resetting to a higher rate",
+			loan: loan{principal: 200000, margin: 1, referenceRate: &fixed,
+				nextReset: time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), resetMonths: 12, remainingMonths: 240, linear: true},
+			reference:     3,
+			wantRate:      3,
+			wantPayment:   1333.33,
+			wantReset:     time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+			wantProjected: 1483.33,
+		},
+		{
+			name: "zero rate",
+			loan: loan{principal: 200000, margin: 0.5, nextReset: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				resetMonths: 3, remainingMonths: 240},
+			reference:     -0.5,
+			wantRate:      0,
+			wantPayment:   833.33,
+			wantReset:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantProjected: 833.33,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.loan.payments(tt.reference, now)
+
+			if math.Abs(got.effectiveRate-tt.wantRate) > 1e-9 {
+				t.Errorf("effectiveRate = %v, want %v", got.effectiveRate, tt.wantRate)
+			}
+			if math.Abs(got.payment-tt.wantPayment) > 0.01 {
+				t.Errorf("payment = %.2f, want %.2f", got.payment, tt.wantPayment)
+			}
+			if !got.nextReset.Equal(tt.wantReset) {
+				t.Errorf("nextReset = %s, want %s", got.nextReset, tt.wantReset)
+			}
+			if math.Abs(got.projectedPayment-tt.wantProjected) > 0.01 {
+				t.Errorf("projectedPayment = %.2f, want %.2f", got.projectedPayment, tt.wantProjected)
+			}
+		})
+	}
+}
+
+func TestExporterCollectsLoans(t *testing.T) {
+	src := &fakeSource{
+		name:  sourceDaily,
+		rates: map[string]float64{"12M": 2.5},
+	}
+	publication, err := calendar.ParsePublication("11:00", calendar.DefaultLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exporter, registry := newTestExporter(t, src, 0, false)
+	exporter.Reload(exporterConfig{
+		sources:     exporter.current().sources,
+		maturities:  []string{"3M", "12M"},
+		concurrency: 2,
+		calendar:    publicationCalendar{publication: publication, lag: 1},
+		loans: []loan{
+			{name: "home", principal: 200000, margin: 0.5, maturity: "12M",
+				nextReset: time.Now().AddDate(0, 0, 1), resetMonths: 12, remainingMonths: 240},
+			{name: "cabin", principal: 50000, margin: 1, maturity: "3M",
+				nextReset: time.Now().AddDate(0, 0, 1), resetMonths: 3, remainingMonths: 120},
+		},
+	})
+
+	poll(exporter)
+
+	// The 3M fixing is missing, so only the 12M loan is reported
+	want := `
+# HELP euribor_loan_effective_rate_percent Interest rate of a configured loan until its next reset, reference rate plus margin, in percent
+# TYPE euribor_loan_effective_rate_percent gauge
+euribor_loan_effective_rate_percent{loan="home"} 3
+# HELP euribor_loan_projected_effective_rate_percent Interest rate of a configured loan after its next reset at the current daily reference rate, in percent
+# TYPE euribor_loan_projected_effective_rate_percent gauge
+euribor_loan_projected_effective_rate_percent{loan="home"} 3
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_loan_effective_rate_percent", "euribor_loan_projected_effective_rate_percent")
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, name := range []string{"euribor_loan_payment_eur", "euribor_loan_projected_payment_eur", "euribor_loan_next_reset_timestamp_seconds"} {
+		if n := testutil.CollectAndCount(exporter, name); n != 1 {
+			t.Errorf("exported %d %s series, want 1", n, name)
+		}
+	}
+}
@@ -2,25 +2,26 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/GoGstickGo/euribor-exporter/scraper"
+	"github.com/GoGstickGo/euribor-exporter/config"
+	"github.com/GoGstickGo/euribor-exporter/ecb"
+	"github.com/GoGstickGo/euribor-exporter/source"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	namespace = "euribor"
-	ecbAPIURL = "https://data-api.ecb.europa.eu/service/data/FM"
 )
 
 var (
@@ -30,59 +31,21 @@ var (
 var (
 	log = logrus.New()
 
-	// Command-line flags
-	listenAddress  = flag.String("listen-address", ":9100", "Address to listen on for web interface and telemetry")
-	metricsPath    = flag.String("metrics-path", "/metrics", "Path under which to expose metrics")
-	scrapeInterval = flag.Duration("scrape-interval", 1*time.Hour, "Interval between scrapes")
+	// Command-line flags. Flags that are set explicitly override the config file.
+	configFile      = flag.String("config.file", "", "Path to the YAML configuration file")
+	configCheck     = flag.Bool("config.check", false, "Validate the configuration and exit")
+	listenAddress   = flag.String("listen-address", ":9100", "Address to listen on for web interface and telemetry")
+	metricsPath     = flag.String("metrics-path", "/metrics", "Path under which to expose metrics")
+	scrapeInterval  = flag.Duration("scrape-interval", 1*time.Hour, "Interval between scrapes (all sources)")
+	ecbAPIURL       = flag.String("ecb-api-url", ecb.DefaultBaseURL, "Base URL of the ECB SDMX data API")
+	legacyNames     = flag.Bool("metrics.legacy-names", false, "Also export the metric names used before the unified schema")
+	storagePath     = flag.String("storage.path", "", "File to keep the last known rates in across restarts (empty disables)")
+	historyPath     = flag.String("storage.history-path", "", "File to keep the history of accepted rates in (empty keeps it in memory)")
+	enableLifecycle = flag.Bool("web.enable-lifecycle", false, "Enable configuration reloads over HTTP (POST /-/reload)")
 )
 
-// Prometheus metrics
+// Process-wide metrics. Per-series metrics are collected by EuriborExporter.
 var (
-	euriborRate = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "rate_percent",
-			Help:      "Current Euribor rate in percent",
-		},
-		[]string{"maturity"},
-	)
-
-	euriborLastUpdate = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "last_update_timestamp",
-			Help:      "Timestamp of last successful Euribor data fetch",
-		},
-		[]string{"maturity"},
-	)
-
-	euriborPubDate = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "last_publication_date",
-			Help:      "Timestamp of last successful Euribor data fetch",
-		},
-		[]string{"maturity"},
-	)
-
-	euriborScrapeDuration = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "scrape_duration_seconds",
-			Help:      "Duration of Euribor data scrape",
-		},
-		[]string{"maturity"},
-	)
-
-	euriborScrapeSuccess = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "scrape_success",
-			Help:      "Whether the last scrape was successful (1 = success, 0 = failure)",
-		},
-		[]string{"maturity"},
-	)
-
 	euriborInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
@@ -92,393 +55,288 @@ var (
 		[]string{"version", "source"},
 	)
 
-	euriborDailyRate = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "daily_rate_percent",
-			Help:      "Daily Euribor rate in percent (scraped from euribor-rates.eu)",
-		},
-		[]string{"maturity"},
-	)
-
-	euriborDailyPublicationDate = prometheus.NewGaugeVec(
+	euriborSourceCircuitState = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
-			Name:      "daily_publication_date_timestamp",
-			Help:      "ECB publication date of the daily Euribor rate (Unix timestamp)",
+			Name:      "source_circuit_state",
+			Help:      "State of the circuit breaker of each source (0 = closed, 1 = open, 2 = half-open)",
 		},
-		[]string{"maturity"},
+		[]string{"source"},
 	)
 
-	euriborDailyScrapeSuccess = prometheus.NewGaugeVec(
+	configLastReloadSuccessful = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
-			Name:      "daily_scrape_success",
-			Help:      "Whether the last daily scrape was successful (1 = success, 0 = failure)",
+			Name:      "config_last_reload_successful",
+			Help:      "Whether the last configuration reload attempt was successful (1 = success, 0 = failure)",
 		},
-		[]string{"maturity"},
 	)
 
-	euriborDailyScrapeDuration = prometheus.NewGaugeVec(
+	configLastReloadSuccessTimestamp = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: namespace,
-			Name:      "daily_scrape_duration_seconds",
-			Help:      "Duration of daily Euribor scrape in seconds",
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload",
 		},
-		[]string{"maturity"},
 	)
 )
 
-// Maturity codes mapping
-var maturities = map[string]string{
-	"1M":  "1MD_",
-	"3M":  "3MD_",
-	"6M":  "6MD_",
-	"12M": "1YD_",
-}
-
-// ECB API response structures
-type ECBResponse struct {
-	DataSets  []DataSet `json:"dataSets"`
-	Structure Structure `json:"structure"`
-}
-
-type DataSet struct {
-	Series map[string]Series `json:"series"`
-}
-
-type Series struct {
-	Observations map[string][]float64 `json:"observations"`
+func init() {
+	// Configure logging
+	log.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+	log.SetLevel(logrus.InfoLevel)
 }
 
-type Structure struct {
-	Dimensions Dimensions `json:"dimensions"`
+// setExporterInfo publishes one exporter_info series per registered source
+func setExporterInfo(sources *source.Registry) {
+	euriborInfo.Reset()
+	for _, name := range sources.Names() {
+		euriborInfo.WithLabelValues(version, name).Set(1)
+	}
 }
 
-type Dimensions struct {
-	Observation []Dimension `json:"observation"`
-}
+// newRegistry creates the registry served on the metrics path
+func newRegistry(exporter *EuriborExporter) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
 
-type Dimension struct {
-	Values []DimensionValue `json:"values"`
-}
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
-type DimensionValue struct {
-	ID string `json:"id"`
-}
+	registry.MustRegister(exporter)
+	registry.MustRegister(euriborInfo)
+	registry.MustRegister(euriborSourceCircuitState)
+	registry.MustRegister(configLastReloadSuccessful)
+	registry.MustRegister(configLastReloadSuccessTimestamp)
 
-// EuriborExporter handles fetching and exposing Euribor rates from multiple sources
-type EuriborExporter struct {
-	client     *http.Client
-	scraper    *scraper.Scraper
-	ecbEnabled bool // Flag to enable/disable ECB source
+	return registry
 }
 
-// NewEuriborExporter creates a new exporter instance
-func NewEuriborExporter(enableECB bool) *EuriborExporter {
-	return &EuriborExporter{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		scraper:    scraper.New(log),
-		ecbEnabled: enableECB,
+// loadConfig builds the effective configuration: defaults, then the config
+// file, then environment variables, then explicitly set flags
+func loadConfig(path string) (*config.Config, error) {
+	cfg := config.Default()
+	if path != "" {
+		var err error
+		if cfg, err = config.Load(path); err != nil {
+			return nil, err
+		}
 	}
-}
 
-// FetchRateFromECB fetches the Euribor rate from ECB API (monthly data)
-func (e *EuriborExporter) FetchRateFromECB(maturity string) (float64, time.Time, error) {
-	maturityCode, exists := maturities[maturity]
-	if !exists {
-		return 0, time.Time{}, fmt.Errorf("invalid maturity: %s", maturity)
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
 	}
 
-	// Build the query
-	key := fmt.Sprintf("M.U2.EUR.RT.MM.EURIBOR%s.HSTA", maturityCode)
-	url := fmt.Sprintf("%s/%s?format=jsondata&detail=dataonly&lastNObservations=1", ecbAPIURL, key)
-
-	log.WithFields(logrus.Fields{
-		"maturity": maturity,
-		"url":      url,
-	}).Debug("Fetching Euribor rate from ECB")
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen-address":
+			cfg.Web.ListenAddress = *listenAddress
+		case "metrics-path":
+			cfg.Web.MetricsPath = *metricsPath
+		case "scrape-interval":
+			cfg.SetInterval(*scrapeInterval)
+		case "ecb-api-url":
+			cfg.Sources.ECB.URL = *ecbAPIURL
+		case "metrics.legacy-names":
+			cfg.Metrics.LegacyNames = *legacyNames
+		case "storage.path":
+			cfg.Storage.Path = *storagePath
+		case "storage.history-path":
+			cfg.Storage.HistoryPath = *historyPath
+		}
+	})
 
-	resp, err := e.client.Get(url)
-	if err != nil {
-		return 0, time.Time{}, fmt.Errorf("failed to fetch data: %w", err)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, time.Time{}, fmt.Errorf("ECB API returned status %d", resp.StatusCode)
-	}
+	return cfg, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, time.Time{}, fmt.Errorf("failed to read response: %w", err)
-	}
+// reloadConfig re-reads the configuration and applies it to the running
+// exporter. Settings that need a restart (listen address, metrics path,
+// storage path) are reported but left unchanged.
+func reloadConfig(exporter *EuriborExporter, running *config.Config) (*config.Config, error) {
+	log.WithField("config_file", *configFile).Info("Reloading configuration")
 
-	var ecbResp ECBResponse
-	if err := json.Unmarshal(body, &ecbResp); err != nil {
-		return 0, time.Time{}, fmt.Errorf("failed to parse JSON: %w", err)
+	cfg, sources, err := loadAndBuild(*configFile)
+	if err != nil {
+		configLastReloadSuccessful.Set(0)
+		log.WithError(err).Error("Failed to reload configuration, keeping the running one")
+		return nil, err
 	}
 
-	// Extract the rate from the response
-	if len(ecbResp.DataSets) == 0 {
-		return 0, time.Time{}, fmt.Errorf("no datasets in response")
+	if cfg.Web != running.Web {
+		log.WithFields(logrus.Fields{
+			"listen_address": running.Web.ListenAddress,
+			"metrics_path":   running.Web.MetricsPath,
+		}).Warn("Changes to the web section require a restart and were not applied")
+		cfg.Web = running.Web
 	}
-
-	series, exists := ecbResp.DataSets[0].Series["0:0:0:0:0:0:0"]
-	if !exists {
-		return 0, time.Time{}, fmt.Errorf("series not found in response")
+	if cfg.Storage != running.Storage {
+		log.WithFields(logrus.Fields{
+			"path":         running.Storage.Path,
+			"history_path": running.Storage.HistoryPath,
+		}).Warn("Changes to the storage section require a restart and were not applied")
+		cfg.Storage = running.Storage
 	}
 
-	if len(series.Observations) == 0 {
-		return 0, time.Time{}, fmt.Errorf("no observations in series")
-	}
+	lvl, _ := logrus.ParseLevel(cfg.Log.Level) // Validated by loadConfig
+	log.SetLevel(lvl)
 
-	// Find the latest observation
-	var latestKey string
-	for key := range series.Observations {
-		if latestKey == "" || key > latestKey {
-			latestKey = key
-		}
-	}
+	setBreakerPolicy(breakerPolicy(cfg))
+	exporter.Reload(exporterConfigFromConfig(cfg, sources))
+	setExporterInfo(sources)
 
-	observations := series.Observations[latestKey]
-	if len(observations) == 0 {
-		return 0, time.Time{}, fmt.Errorf("observation is empty")
-	}
+	configLastReloadSuccessful.Set(1)
+	configLastReloadSuccessTimestamp.SetToCurrentTime()
 
-	rate := observations[0]
-
-	// Parse publication date (monthly format: "2025-11")
-	pubDate := time.Now() // Default to current time
-
-	if len(ecbResp.Structure.Dimensions.Observation) > 0 {
-		timeDim := ecbResp.Structure.Dimensions.Observation[0]
-		if len(timeDim.Values) > 0 {
-			// Get the last value (most recent)
-			latestIdx := len(timeDim.Values) - 1
-			dateStr := timeDim.Values[latestIdx].ID
-
-			// ECB monthly data returns "2025-11" format
-			parsed, err := time.Parse("2006-01", dateStr)
-			if err != nil {
-				log.WithFields(logrus.Fields{
-					"maturity": maturity,
-					"date_str": dateStr,
-					"error":    err,
-				}).Warn("Failed to parse ECB publication date, using current time")
-			} else {
-				// Set to last day of the month for more accuracy
-				pubDate = time.Date(parsed.Year(), parsed.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-
-				log.WithFields(logrus.Fields{
-					"maturity": maturity,
-					"pub_date": pubDate.Format("2006-01"),
-				}).Debug("Parsed ECB publication date")
-			}
-		}
-	}
+	log.WithFields(logrus.Fields{
+		"maturities": cfg.Maturities,
+		"sources":    sources.Names(),
+	}).Info("Configuration reloaded")
 
-	return rate, pubDate, nil
+	return cfg, nil
 }
 
-// FetchRateFromWeb fetches the Euribor rate from web scraper (daily data)
-func (e *EuriborExporter) FetchRateFromWeb(maturity string) (float64, time.Time, error) {
-	data, err := e.scraper.FetchRate(maturity)
+// loadAndBuild loads the configuration and creates the sources it enables
+func loadAndBuild(path string) (*config.Config, *source.Registry, error) {
+	cfg, err := loadConfig(path)
 	if err != nil {
-		return 0, time.Time{}, err
-	}
-
-	return data.Rate, data.PublicationDate, nil
-}
-
-// UpdateMetrics fetches latest rates from both sources and updates Prometheus metrics
-func (e *EuriborExporter) UpdateMetrics() {
-	maturitiesList := scraper.GetSupportedMaturities()
-
-	for _, maturity := range maturitiesList {
-		// Fetch from daily web scraper
-		e.updateDailyMetrics(maturity)
-
-		// Fetch from ECB (monthly) if enabled
-		if e.ecbEnabled {
-			e.updateECBMetrics(maturity)
-		}
+		return nil, nil, err
 	}
-}
-
-// updateDailyMetrics fetches and updates daily scraped metrics
-func (e *EuriborExporter) updateDailyMetrics(maturity string) {
-	startTime := time.Now()
-
-	rate, pubDate, err := e.FetchRateFromWeb(maturity)
-	duration := time.Since(startTime).Seconds()
-
-	euriborDailyScrapeDuration.WithLabelValues(maturity).Set(duration)
 
+	sources, err := buildSources(cfg)
 	if err != nil {
-		log.WithFields(logrus.Fields{
-			"maturity": maturity,
-			"source":   "daily-scraper",
-			"error":    err,
-		}).Error("Failed to fetch daily Euribor rate")
-		euriborDailyScrapeSuccess.WithLabelValues(maturity).Set(0)
-		return
+		return nil, nil, err
+	}
+	if err := checkMaturities(sources, cfg.Maturities); err != nil {
+		return nil, nil, err
 	}
 
-	// Update daily metrics
-	euriborDailyRate.WithLabelValues(maturity).Set(rate)
-	euriborDailyPublicationDate.WithLabelValues(maturity).Set(float64(pubDate.Unix()))
-	euriborDailyScrapeSuccess.WithLabelValues(maturity).Set(1)
-
-	log.WithFields(logrus.Fields{
-		"maturity": maturity,
-		"source":   "daily-scraper",
-		"rate":     rate,
-		"pub_date": pubDate.Format("2006-01-02"),
-		"duration": duration,
-	}).Info("Updated daily Euribor metric")
+	return cfg, sources, nil
 }
 
-// updateECBMetrics fetches and updates ECB monthly metrics
-func (e *EuriborExporter) updateECBMetrics(maturity string) {
-	// Only fetch ECB data if maturity exists in maturities map
-	if _, exists := maturities[maturity]; !exists {
-		log.WithFields(logrus.Fields{
-			"maturity": maturity,
-			"source":   "ecb",
-		}).Debug("Skipping ECB fetch - maturity not supported by ECB API")
+func main() {
+	// Subcommands are dispatched before the exporter flags are parsed
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(os.Args[2:]); err != nil {
+			log.WithError(err).Fatal("Backfill failed")
+		}
 		return
 	}
 
-	startTime := time.Now()
+	flag.Parse()
 
-	rate, pubDate, err := e.FetchRateFromECB(maturity)
-	duration := time.Since(startTime).Seconds()
+	// Load configuration and register rate sources
+	cfg, sources, err := loadAndBuild(*configFile)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid configuration")
+	}
 
-	euriborScrapeDuration.WithLabelValues(maturity).Set(duration)
+	lvl, _ := logrus.ParseLevel(cfg.Log.Level) // Validated by loadConfig
+	log.SetLevel(lvl)
 
-	if err != nil {
-		log.WithFields(logrus.Fields{
-			"maturity": maturity,
-			"source":   "ecb",
-			"error":    err,
-		}).Error("Failed to fetch ECB Euribor rate")
-		euriborScrapeSuccess.WithLabelValues(maturity).Set(0)
+	if *configCheck {
+		fmt.Println("Configuration is valid")
 		return
 	}
 
-	// Update ECB metrics
-	euriborRate.WithLabelValues(maturity).Set(rate)
-	euriborPubDate.WithLabelValues(maturity).Set(float64(pubDate.Unix()))
-	euriborScrapeSuccess.WithLabelValues(maturity).Set(1)
-
 	log.WithFields(logrus.Fields{
-		"maturity": maturity,
-		"source":   "ecb",
-		"rate":     rate,
-		"pub_date": pubDate.Format("2006-01-02"),
-		"duration": duration,
-	}).Info("Updated ECB Euribor metric")
-}
-
-// Run starts the periodic metric updates
-func (e *EuriborExporter) Run(interval time.Duration, stopCh <-chan struct{}) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// Initial update
-	log.Info("Performing initial metrics update")
-	e.UpdateMetrics()
+		"version":        version,
+		"config_file":    *configFile,
+		"listen_address": cfg.Web.ListenAddress,
+		"metrics_path":   cfg.Web.MetricsPath,
+		"maturities":     cfg.Maturities,
+		"sources":        sources.Names(),
+		"concurrency":    cfg.Concurrency,
+	}).Info("Starting Euribor Prometheus Exporter")
 
-	for {
-		select {
-		case <-ticker.C:
-			log.Info("Performing scheduled metrics update")
-			e.UpdateMetrics()
-		case <-stopCh:
-			log.Info("Stopping exporter")
-			return
+	// Create exporter
+	exporter := NewEuriborExporter(exporterConfigFromConfig(cfg, sources))
+	setExporterInfo(sources)
+	if cfg.Storage.Path != "" {
+		if err := exporter.OpenState(cfg.Storage.Path); err != nil {
+			log.WithError(err).Warn("Failed to restore state, starting empty")
 		}
 	}
-}
-
-func init() {
-	// Register metrics
-	prometheus.MustRegister(euriborRate)
-	prometheus.MustRegister(euriborLastUpdate)
-	prometheus.MustRegister(euriborScrapeDuration)
-	prometheus.MustRegister(euriborScrapeSuccess)
-	prometheus.MustRegister(euriborInfo)
-	prometheus.MustRegister(euriborPubDate)
-
-	prometheus.MustRegister(euriborDailyRate)
-	prometheus.MustRegister(euriborDailyPublicationDate)
-	prometheus.MustRegister(euriborDailyScrapeSuccess)
-	prometheus.MustRegister(euriborDailyScrapeDuration)
-
-	// Set exporter info
-	euriborInfo.WithLabelValues(version, "dual-source: ECB + daily scraper").Set(1)
-
-	// Configure logging
-	log.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
-	log.SetLevel(logrus.InfoLevel)
-}
-
-func main() {
-	flag.Parse()
-
-	// Set log level from environment
-	if level := os.Getenv("LOG_LEVEL"); level != "" {
-		if lvl, err := logrus.ParseLevel(level); err == nil {
-			log.SetLevel(lvl)
+	if cfg.Storage.HistoryPath != "" {
+		if err := exporter.OpenHistory(cfg.Storage.HistoryPath); err != nil {
+			log.WithError(err).Warn("Failed to open rate history, keeping it in memory only")
 		}
 	}
+	registry := newRegistry(exporter)
+	configLastReloadSuccessful.Set(1)
+	configLastReloadSuccessTimestamp.SetToCurrentTime()
 
-	// Check if ECB source should be enabled (default: true for backward compatibility)
-	enableECB := os.Getenv("ENABLE_ECB") != "false"
-
-	log.WithFields(logrus.Fields{
-		"version":         version,
-		"listen_address":  *listenAddress,
-		"metrics_path":    *metricsPath,
-		"scrape_interval": *scrapeInterval,
-		"ecb_enabled":     enableECB,
-	}).Info("Starting Euribor Prometheus Exporter")
-
-	// Create exporter
-	exporter := NewEuriborExporter(enableECB)
-
-	// Setup signal handling for graceful shutdown
-	stopCh := make(chan struct{})
+	// Setup signal handling for graceful shutdown and reloads. Cancelling the
+	// root context aborts every in-flight fetch.
+	ctx, cancelRoot := context.WithCancelCause(context.Background())
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	reloadCh := make(chan chan error)
 
 	// Start the exporter in a goroutine
-	go exporter.Run(*scrapeInterval, stopCh)
+	exporterDone := make(chan struct{})
+	go func() {
+		defer close(exporterDone)
+		exporter.Run(ctx)
+	}()
 
 	// Setup HTTP server
-	http.Handle(*metricsPath, promhttp.Handler())
+	http.Handle(cfg.Web.MetricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		settings := exporter.current()
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `<html>
 <head><title>Euribor Exporter</title></head>
 <body>
 <h1>Euribor Prometheus Exporter</h1>
 <p><a href="%s">Metrics</a></p>
+<p><a href="/api/v1/rates/latest">Latest rates (JSON)</a></p>
+<p><a href="/api/v1/rates">Rate history (JSON)</a></p>
+<p><a href="/export.csv">Rate history (CSV)</a></p>
 <h2>Configuration</h2>
 <ul>
-<li>Scrape Interval: %s</li>
-<li>Maturities: 1M, 3M, 6M, 12M</li>
-<li>Data Source: ECB Statistical Data Warehouse</li>
+<li>Maturities: %s</li>
+<li>Sources: %s</li>
 </ul>
 </body>
-</html>`, *metricsPath, *scrapeInterval)
+</html>`, cfg.Web.MetricsPath, strings.Join(settings.maturityList(), ", "), strings.Join(settings.sources.Names(), ", "))
 	})
 
+	http.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
+		if !*enableLifecycle {
+			http.Error(w, "Lifecycle API is not enabled, start with --web.enable-lifecycle", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rc := make(chan error)
+		select {
+		case reloadCh <- rc:
+		case <-r.Context().Done():
+			return
+		}
+
+		if err := <-rc; err != nil {
+			http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "OK")
+	})
+
+	http.Handle("/api/v1/rates", ratesHandler(exporter))
+	http.Handle("/api/v1/rates/latest", latestHandler(exporter))
+	http.Handle("/export.csv", exportCSVHandler(exporter))
+
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK")
@@ -486,29 +344,54 @@ func main() {
 
 	// Start HTTP server in a goroutine
 	server := &http.Server{
-		Addr:         *listenAddress,
+		Addr:         cfg.Web.ListenAddress,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
 	go func() {
-		log.WithField("address", *listenAddress).Info("Starting HTTP server")
+		log.WithField("address", cfg.Web.ListenAddress).Info("Starting HTTP server")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.WithError(err).Fatal("Failed to start HTTP server")
 		}
 	}()
 
-	// Wait for shutdown signal
-	<-sigCh
-	log.Info("Received shutdown signal")
+	// Serve reload requests until a shutdown signal arrives
+	running := cfg
+	for shutdown := false; !shutdown; {
+		select {
+		case <-hupCh:
+			if next, err := reloadConfig(exporter, running); err == nil {
+				running = next
+			}
+		case rc := <-reloadCh:
+			next, err := reloadConfig(exporter, running)
+			if err == nil {
+				running = next
+			}
+			rc <- err
+		case sig := <-sigCh:
+			log.WithField("signal", sig).Info("Received shutdown signal")
+			cancelRoot(fmt.Errorf("%w: received %s", errShutdown, sig))
+			shutdown = true
+		}
+	}
 
 	// Graceful shutdown
-	close(stopCh)
-
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	select {
+	case <-exporterDone:
+		// Nothing appends to the history once Run has returned
+		if err := exporter.CloseHistory(); err != nil {
+			log.WithError(err).Error("Failed to close rate history")
+		}
+	case <-shutdownCtx.Done():
+		log.Warn("Exporter did not stop within the shutdown window")
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.WithError(err).Error("Server shutdown error")
 	}
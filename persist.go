@@ -0,0 +1,122 @@
+package main
+
+import (
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/history"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/GoGstickGo/euribor-exporter/state"
+	"github.com/sirupsen/logrus"
+)
+
+// OpenState restores the rates and fetch history saved at path and saves them
+// there after every accepted rate from now on. Restored series are exported
+// right away, flagged by euribor_series_restored, until they are fetched
+// again. If the file cannot be read the exporter starts empty and the error
+// is returned; the file is overwritten by the next save.
+func (e *EuriborExporter) OpenState(path string) error {
+	e.stateMu.Lock()
+	e.statePath = path
+	e.stateMu.Unlock()
+
+	st, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+
+	active := e.current().activeSeries()
+	restored := 0
+	for _, s := range st.Series {
+		key := seriesKey{source: s.Source, maturity: s.Maturity}
+		if !active[key] {
+			continue
+		}
+		e.snapshots.restore(key, source.Rate{
+			Rate:            s.Rate,
+			PublicationDate: s.PublicationDate,
+			Frequency:       s.Frequency,
+		}, s.LastSuccess)
+		restored++
+	}
+
+	for _, o := range st.Observations {
+		e.reconciler.observe(o.Maturity, source.Rate{
+			Rate:            o.Rate,
+			PublicationDate: o.PublicationDate,
+			Frequency:       o.Frequency,
+		})
+	}
+
+	log.WithFields(logrus.Fields{
+		"path":         path,
+		"series":       restored,
+		"observations": len(st.Observations),
+		"saved_at":     st.SavedAt,
+	}).Info("Restored state")
+
+	return nil
+}
+
+// saveState writes the current rates and fetch history if a state file is open
+func (e *EuriborExporter) saveState() {
+	e.stateMu.Lock()
+	defer e.stateMu.Unlock()
+
+	if e.statePath == "" {
+		return
+	}
+
+	st := &state.State{
+		SavedAt:      time.Now(),
+		Series:       e.snapshots.persisted(),
+		Observations: e.reconciler.observations(),
+	}
+	if err := state.Save(e.statePath, st); err != nil {
+		log.WithError(err).WithField("path", e.statePath).Warn("Failed to save state")
+	}
+}
+
+// OpenHistory loads the rate history saved at path and appends every new
+// accepted rate to it. It must be called before Run; on error the history is
+// kept in memory only.
+func (e *EuriborExporter) OpenHistory(path string) error {
+	store, err := history.Open(path)
+	if err != nil {
+		return err
+	}
+	e.history = store
+
+	if store.Repaired() {
+		log.WithField("path", path).Warn("Dropped a partly written last line from the rate history")
+	}
+	log.WithFields(logrus.Fields{
+		"path":         path,
+		"observations": len(store.Query(history.Query{})),
+	}).Info("Opened rate history")
+
+	return nil
+}
+
+// CloseHistory closes the file opened by OpenHistory. It must be called after
+// Run has returned.
+func (e *EuriborExporter) CloseHistory() error {
+	return e.history.Close()
+}
+
+// recordHistory adds an accepted rate to the history unless it is already known
+func (e *EuriborExporter) recordHistory(name, maturity string, rate *source.Rate, observedAt time.Time) {
+	_, err := e.history.Add(history.Observation{
+		Source:          name,
+		Maturity:        maturity,
+		PublicationDate: rate.PublicationDate,
+		Rate:            rate.Rate,
+		Frequency:       rate.Frequency,
+		ObservedAt:      observedAt,
+	})
+	if err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			"maturity": maturity,
+			"source":   name,
+		}).Warn("Failed to record rate history")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/history"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExporterRestoresState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081},
+	}
+	first, _ := newTestExporter(t, src, time.Hour, false)
+	if err := first.OpenState(path); err != nil {
+		t.Fatalf("OpenState() on missing file unexpected error: %v", err)
+	}
+	poll(first)
+
+	// After a restart with the upstream down the saved rate is served
+	delete(src.rates, "3M")
+	second, registry := newTestExporter(t, src, time.Hour, false)
+	if err := second.OpenState(path); err != nil {
+		t.Fatalf("OpenState() unexpected error: %v", err)
+	}
+
+	// Nothing has been fetched since the restart
+	want := `
+# HELP euribor_fetch_success Whether the last fetch was successful (1 = success, 0 = failure)
+# TYPE euribor_fetch_success gauge
+euribor_fetch_success{maturity="3M",source="fake"} 0
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="fake"} 2.081
+# HELP euribor_series_restored Whether the exported rate was restored from the state file and has not been fetched since the restart (1 = restored)
+# TYPE euribor_series_restored gauge
+euribor_series_restored{maturity="3M",source="fake"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_fetch_success", "euribor_rate_percent", "euribor_series_restored"); err != nil {
+		t.Error(err)
+	}
+
+	// A failed fetch keeps the restored rate, a successful one replaces it
+	poll(second)
+	src.rates["3M"] = 2.09
+	poll(second)
+
+	want = `
+# HELP euribor_rate_percent Euribor rate in percent
+# TYPE euribor_rate_percent gauge
+euribor_rate_percent{frequency="daily",maturity="3M",source="fake"} 2.09
+# HELP euribor_series_restored Whether the exported rate was restored from the state file and has not been fetched since the restart (1 = restored)
+# TYPE euribor_series_restored gauge
+euribor_series_restored{maturity="3M",source="fake"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want),
+		"euribor_rate_percent", "euribor_series_restored"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExporterHistoryOutlivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	src := &fakeSource{
+		name:  "fake",
+		rates: map[string]float64{"3M": 2.081},
+	}
+	first, _ := newTestExporter(t, src, time.Hour, false)
+	if err := first.OpenHistory(path); err != nil {
+		t.Fatalf("OpenHistory() on missing file unexpected error: %v", err)
+	}
+	poll(first)
+	if err := first.CloseHistory(); err != nil {
+		t.Fatalf("CloseHistory() unexpected error: %v", err)
+	}
+
+	second, _ := newTestExporter(t, src, time.Hour, false)
+	if err := second.OpenHistory(path); err != nil {
+		t.Fatalf("OpenHistory() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { second.CloseHistory() })
+
+	got := second.history.Query(history.Query{Maturity: "3M"})
+	if len(got) != 1 || got[0].Rate != 2.081 {
+		t.Errorf("history after restart = %+v, want the 3M rate 2.081", got)
+	}
+}
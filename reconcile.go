@@ -0,0 +1,170 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/GoGstickGo/euribor-exporter/state"
+)
+
+// reconcileMonths is how many months of observations the reconciler keeps
+const reconcileMonths = 3
+
+// reconciler averages the daily fixings observed in each month so they can be
+// compared with the monthly averages published by the ECB for the same month
+type reconciler struct {
+	mu      sync.Mutex
+	daily   map[string]map[string]map[time.Time]float64 // Maturity, period, fixing date
+	monthly map[string]map[string]float64               // Maturity, period
+}
+
+// divergence compares one maturity and month across frequencies
+type divergence struct {
+	maturity string
+	period   string  // Month as YYYY-MM
+	bp       float64 // Average of the daily fixings minus the monthly average, in basis points
+	coverage float64 // Share of the month's TARGET business days with an observed fixing
+}
+
+func newReconciler() *reconciler {
+	return &reconciler{
+		daily:   make(map[string]map[string]map[time.Time]float64),
+		monthly: make(map[string]map[string]float64),
+	}
+}
+
+func period(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// observe records an accepted rate. Rates of other frequencies are ignored.
+// It reports whether rate is the first monthly average seen for its month.
+func (r *reconciler) observe(maturity string, rate source.Rate) (newMonth bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := period(rate.PublicationDate)
+
+	switch rate.Frequency {
+	case source.Daily:
+		if r.daily[maturity] == nil {
+			r.daily[maturity] = make(map[string]map[time.Time]float64)
+		}
+		if r.daily[maturity][p] == nil {
+			r.daily[maturity][p] = make(map[time.Time]float64)
+		}
+		r.daily[maturity][p][rate.PublicationDate] = rate.Rate
+	case source.Monthly:
+		if r.monthly[maturity] == nil {
+			r.monthly[maturity] = make(map[string]float64)
+		}
+		_, known := r.monthly[maturity][p]
+		newMonth = !known
+		r.monthly[maturity][p] = rate.Rate
+	default:
+		return false
+	}
+
+	// Forget months that will not be published or compared any more
+	oldest := period(rate.PublicationDate.AddDate(0, -reconcileMonths, 0))
+	for old := range r.daily[maturity] {
+		if old < oldest {
+			delete(r.daily[maturity], old)
+		}
+	}
+	for old := range r.monthly[maturity] {
+		if old < oldest {
+			delete(r.monthly[maturity], old)
+		}
+	}
+	return newMonth
+}
+
+// coverage returns the share of the TARGET business days of the YYYY-MM
+// period p with an observed daily fixing of maturity
+func (r *reconciler) coverage(maturity, p string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.coverageLocked(maturity, p)
+}
+
+func (r *reconciler) coverageLocked(maturity, p string) float64 {
+	days := businessDaysInMonth(p)
+	if days == 0 {
+		return 0
+	}
+	return min(1, float64(len(r.daily[maturity][p]))/float64(days))
+}
+
+// divergences returns every month for which both daily fixings and a monthly
+// average have been observed
+func (r *reconciler) divergences() []divergence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []divergence
+	for maturity, months := range r.monthly {
+		for p, average := range months {
+			fixings := r.daily[maturity][p]
+			if len(fixings) == 0 {
+				continue
+			}
+
+			sum := 0.0
+			for _, rate := range fixings {
+				sum += rate
+			}
+
+			result = append(result, divergence{
+				maturity: maturity,
+				period:   p,
+				bp:       basisPoints(sum/float64(len(fixings)) - average),
+				coverage: r.coverageLocked(maturity, p),
+			})
+		}
+	}
+	return result
+}
+
+// observations returns every rate kept for reconciliation, for the state file
+func (r *reconciler) observations() []state.Observation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []state.Observation
+	for maturity, months := range r.daily {
+		for _, fixings := range months {
+			for date, rate := range fixings {
+				result = append(result, state.Observation{
+					Maturity:        maturity,
+					Frequency:       source.Daily,
+					PublicationDate: date,
+					Rate:            rate,
+				})
+			}
+		}
+	}
+	for maturity, months := range r.monthly {
+		for p, rate := range months {
+			date, _ := time.Parse("2006-01", p)
+			result = append(result, state.Observation{
+				Maturity:        maturity,
+				Frequency:       source.Monthly,
+				PublicationDate: date.AddDate(0, 1, -1),
+				Rate:            rate,
+			})
+		}
+	}
+	return result
+}
+
+// businessDaysInMonth counts the TARGET business days of the YYYY-MM period p
+func businessDaysInMonth(p string) int {
+	first, err := time.Parse("2006-01", p)
+	if err != nil {
+		return 0
+	}
+	return calendar.BusinessDaysBetween(first.AddDate(0, 0, -1), first.AddDate(0, 1, -1))
+}
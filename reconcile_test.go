@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+func TestReconcilerDivergence(t *testing.T) {
+	r := newReconciler()
+
+	daily := func(day int, rate float64) source.Rate {
+		return source.Rate{
+			Rate:            rate,
+			PublicationDate: time.Date(2025, 11, day, 0, 0, 0, 0, time.UTC),
+			Frequency:       source.Daily,
+		}
+	}
+
+	// Observed twice, counted once
+	r.observe("3M", daily(3, 2.0))
+	r.observe("3M", daily(3, 2.0))
+	r.observe("3M", daily(4, 2.1))
+	r.observe("12M", daily(4, 2.2))
+
+	if got := r.divergences(); len(got) != 0 {
+		t.Fatalf("divergences() before any monthly average = %v, want none", got)
+	}
+
+	r.observe("3M", source.Rate{
+		Rate:            2.0,
+		PublicationDate: time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC),
+		Frequency:       source.Monthly,
+	})
+
+	got := r.divergences()
+	if len(got) != 1 {
+		t.Fatalf("divergences() = %v, want one entry", got)
+	}
+	d := got[0]
+	if d.maturity != "3M" || d.period != "2025-11" {
+		t.Errorf("divergence for %s %s, want 3M 2025-11", d.maturity, d.period)
+	}
+	if math.Abs(d.bp-5) > 1e-9 {
+		t.Errorf("divergence = %gbp, want 5bp", d.bp)
+	}
+	// November 2025 has 20 TARGET business days
+	if math.Abs(d.coverage-0.1) > 1e-9 {
+		t.Errorf("coverage = %g, want 0.1", d.coverage)
+	}
+
+	// Months past the retention are forgotten
+	r.observe("3M", daily(3, 2.0))
+	r.observe("3M", source.Rate{
+		Rate:            2.0,
+		PublicationDate: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Frequency:       source.Daily,
+	})
+	if got := r.divergences(); len(got) != 0 {
+		t.Errorf("divergences() after retention = %v, want none", got)
+	}
+}
+
+func TestBusinessDaysInMonth(t *testing.T) {
+	tests := map[string]int{
+		"2025-11": 20,
+		"2025-12": 21, // 25 and 26 December closed
+		"2025-04": 20, // Good Friday and Easter Monday closed
+	}
+
+	for p, want := range tests {
+		if got := businessDaysInMonth(p); got != want {
+			t.Errorf("businessDaysInMonth(%s) = %d, want %d", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy describes how failed HTTP requests are retried
+type Policy struct {
+	MaxAttempts          int           // Total attempts including the first one; 1 disables retries
+	BaseDelay            time.Duration // Delay before the first retry, doubled on every further retry
+	MaxDelay             time.Duration // Upper bound for a single delay, including Retry-After
+	Jitter               float64       // Fraction (0-1) by which each delay is randomly shortened
+	RetryableStatusCodes []int         // Response codes that trigger a retry
+}
+
+// DefaultPolicy returns the policy used when none is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseDelay:   1 * time.Second,
+		MaxDelay:    10 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// Backoff returns the delay before retry number attempt (starting at 1),
+// without jitter
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// jittered shortens delay by a random fraction of up to p.Jitter
+func (p Policy) jittered(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return delay
+	}
+	return delay - time.Duration(rand.Float64()*p.Jitter*float64(delay))
+}
+
+func (p Policy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Observer is called before every retry with the number of the upcoming
+// attempt (starting at 2), the reason for retrying and the delay
+type Observer func(attempt int, reason string, delay time.Duration)
+
+type observerKey struct{}
+
+// WithObserver returns a context that reports retries of requests made with it
+func WithObserver(ctx context.Context, observer Observer) context.Context {
+	return context.WithValue(ctx, observerKey{}, observer)
+}
+
+func observerFrom(ctx context.Context) Observer {
+	if observer, ok := ctx.Value(observerKey{}).(Observer); ok {
+		return observer
+	}
+	return func(int, string, time.Duration) {}
+}
+
+// Transport is an http.RoundTripper that retries transient failures: network
+// errors and retryable response codes. Retry-After headers are honored; if the
+// server asks to wait longer than Policy.MaxDelay the response is returned as is.
+type Transport struct {
+	Policy Policy
+	Next   http.RoundTripper
+}
+
+// NewTransport wraps next with the retry policy p
+func NewTransport(p Policy, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{
+		Policy: p,
+		Next:   next,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	observe := observerFrom(ctx)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.Next.RoundTrip(req)
+
+		// Requests with a body can only be replayed if it can be recreated
+		if attempt >= t.Policy.MaxAttempts || ctx.Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		var reason string
+		delay := t.Policy.jittered(t.Policy.Backoff(attempt))
+
+		switch {
+		case err != nil:
+			reason = "network"
+		case t.Policy.retryableStatus(resp.StatusCode):
+			reason = strconv.Itoa(resp.StatusCode)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > t.Policy.MaxDelay {
+					return resp, nil
+				}
+				if retryAfter > delay {
+					delay = retryAfter
+				}
+			}
+		default:
+			return resp, nil
+		}
+
+		// Release the connection of the discarded response
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		observe(attempt+1, reason, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = fmt.Errorf("giving up after %d attempts, last status %s", attempt, reason)
+			}
+			return nil, fmt.Errorf("%w (retry aborted: %w)", err, context.Cause(ctx))
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
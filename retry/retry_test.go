@@ -0,0 +1,218 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPolicy() Policy {
+	p := DefaultPolicy()
+	p.BaseDelay = time.Millisecond
+	p.MaxDelay = 50 * time.Millisecond
+	p.Jitter = 0
+	return p
+}
+
+// newFlakyServer answers with the given status codes in order, then 200
+func newFlakyServer(t *testing.T, header http.Header, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(codes) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(codes[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func get(t *testing.T, ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestTransportRetriesRetryableStatus(t *testing.T) {
+	srv, calls := newFlakyServer(t, nil, http.StatusServiceUnavailable, http.StatusBadGateway)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	var observed []string
+	ctx := WithObserver(context.Background(), func(attempt int, reason string, delay time.Duration) {
+		observed = append(observed, reason)
+	})
+
+	resp, err := get(t, ctx, client, srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("server called %d times, want 3", *calls)
+	}
+	if len(observed) != 2 || observed[0] != "503" || observed[1] != "502" {
+		t.Errorf("observed retries %v, want [503 502]", observed)
+	}
+}
+
+func TestTransportStopsAtMaxAttempts(t *testing.T) {
+	srv, calls := newFlakyServer(t, nil, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	resp, err := get(t, context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("server called %d times, want 3", *calls)
+	}
+}
+
+func TestTransportDoesNotRetryClientErrors(t *testing.T) {
+	srv, calls := newFlakyServer(t, nil, http.StatusNotFound)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	resp, err := get(t, context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound || *calls != 1 {
+		t.Errorf("got status %d after %d calls, want 404 after 1", resp.StatusCode, *calls)
+	}
+}
+
+func TestTransportHonorsRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"0"}}
+	srv, calls := newFlakyServer(t, header, http.StatusServiceUnavailable)
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+
+	if _, err := get(t, context.Background(), client, srv.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("server called %d times, want 2", *calls)
+	}
+
+	// A Retry-After beyond MaxDelay is returned to the caller instead of waited out
+	header = http.Header{"Retry-After": []string{"3600"}}
+	srv, calls = newFlakyServer(t, header, http.StatusServiceUnavailable)
+
+	resp, err := get(t, context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || *calls != 1 {
+		t.Errorf("got status %d after %d calls, want 503 after 1", resp.StatusCode, *calls)
+	}
+}
+
+func TestTransportRetriesNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	var retries int
+	ctx := WithObserver(context.Background(), func(attempt int, reason string, delay time.Duration) {
+		if reason != "network" {
+			t.Errorf("reason = %q, want network", reason)
+		}
+		retries++
+	})
+
+	client := &http.Client{Transport: NewTransport(testPolicy(), nil)}
+	if _, err := get(t, ctx, client, url); err == nil {
+		t.Fatal("expected error for closed server, got nil")
+	}
+	if retries != 2 {
+		t.Errorf("retried %d times, want 2", retries)
+	}
+}
+
+func TestTransportStopsOnCancel(t *testing.T) {
+	srv, calls := newFlakyServer(t, nil, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	p := testPolicy()
+	p.BaseDelay = time.Hour
+	p.MaxDelay = time.Hour
+	client := &http.Client{Transport: NewTransport(p, nil)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := get(t, ctx, client, srv.URL); err == nil {
+		t.Fatal("expected error after cancellation, got nil")
+	}
+	if *calls != 1 {
+		t.Errorf("server called %d times, want 1", *calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestJitterStaysWithinBounds(t *testing.T) {
+	p := Policy{Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := p.jittered(time.Second); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("jittered(1s) = %s, want within [500ms, 1s]", d)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 12, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"Mon, 15 Dec 2025 10:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 15 Dec 2025 09:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+)
+
+// pollSchedule polls a source around the expected publication time of new
+// fixings instead of on a fixed interval
+type pollSchedule struct {
+	publication    calendar.Publication
+	window         time.Duration // How long after a publication to poll every windowInterval
+	windowInterval time.Duration
+}
+
+// nextPoll returns how long to wait before polling again. lastChange is when
+// the source last returned a newer publication date than before; interval
+// is the fallback used while an expected fixing is overdue.
+//
+// Once the latest expected fixing has been seen the source is left alone
+// until the next publication. Until then it is polled every windowInterval
+// during the window and every interval on the rest of the business day.
+func (s *pollSchedule) nextPoll(now, lastChange time.Time, interval time.Duration) time.Duration {
+	last := s.publication.Last(now)
+	untilNext := s.publication.Next(now).Sub(now)
+
+	switch {
+	case !lastChange.Before(last):
+		return untilNext
+	case now.Before(last.Add(s.window)):
+		return min(s.windowInterval, untilNext)
+	case !calendar.IsBusinessDay(now.In(s.publication.Location)):
+		return untilNext
+	default:
+		return min(interval, untilNext)
+	}
+}
+
+// equal reports whether s and other poll at the same times
+func (s *pollSchedule) equal(other *pollSchedule) bool {
+	return s.publication.Hour == other.publication.Hour &&
+		s.publication.Minute == other.publication.Minute &&
+		s.publication.Location.String() == other.publication.Location.String() &&
+		s.window == other.window &&
+		s.windowInterval == other.windowInterval
+}
+
+// publicationCalendar tells which fixing should be available at a given time
+type publicationCalendar struct {
+	publication calendar.Publication
+	lag         int // Business days between a fixing and its publication
+}
+
+// expectedFixing returns the date of the newest fixing that should have been
+// published by now
+func (c publicationCalendar) expectedFixing(now time.Time) time.Time {
+	day := c.publication.Last(now)
+	for i := 0; i < c.lag; i++ {
+		day = calendar.PrevBusinessDay(day)
+	}
+	return day
+}
+
+// missed returns how many expected fixings are newer than pubDate
+func (c publicationCalendar) missed(pubDate, now time.Time) int {
+	return calendar.BusinessDaysBetween(pubDate, c.expectedFixing(now))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+)
+
+func TestPollScheduleNextPoll(t *testing.T) {
+	publication, err := calendar.ParsePublication("11:00", calendar.DefaultLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule := &pollSchedule{
+		publication:    publication,
+		window:         2 * time.Hour,
+		windowInterval: 5 * time.Minute,
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, publication.Location)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		lastChange time.Time // Zero if no newer fixing has been seen
+		want       time.Duration
+	}{
+		// Tuesday 10 March 2026
+		{"before the publication, yesterday's fixing missing", at(time.March, 10, 9, 0), time.Time{}, time.Hour},
+		{"inside the window", at(time.March, 10, 11, 20), time.Time{}, 5 * time.Minute},
+		{"inside the window, yesterday's fixing seen", at(time.March, 10, 11, 20), at(time.March, 9, 11, 10), 5 * time.Minute},
+		{"after the window on a business day", at(time.March, 10, 14, 0), time.Time{}, time.Hour},
+		{"fixing seen in the window", at(time.March, 10, 11, 10), at(time.March, 10, 11, 10), 23*time.Hour + 50*time.Minute},
+		{"fixing seen after the window", at(time.March, 10, 15, 0), at(time.March, 10, 15, 0), 20 * time.Hour},
+
+		// Saturday 14 March 2026, the next publication is on Monday
+		{"weekend, Friday's fixing seen", at(time.March, 14, 10, 0), at(time.March, 13, 11, 10), 49 * time.Hour},
+		{"weekend, Friday's fixing missing", at(time.March, 14, 10, 0), at(time.March, 12, 11, 10), 49 * time.Hour},
+
+		// Good Friday 3 April 2026, the next publication is on Tuesday after Easter Monday
+		{"Easter, Thursday's fixing seen", at(time.April, 3, 12, 0), at(time.April, 2, 11, 10), 95 * time.Hour},
+		{"Easter, Thursday's fixing missing", at(time.April, 3, 12, 0), at(time.April, 1, 11, 10), 95 * time.Hour},
+
+		// Never waits past the next publication
+		{"interval longer than the wait for the next publication", at(time.March, 10, 10, 30), time.Time{}, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.nextPoll(tt.now, tt.lastChange, time.Hour); got != tt.want {
+				t.Errorf("nextPoll() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollStateObserve(t *testing.T) {
+	monday := time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+	now := time.Date(2026, time.March, 10, 11, 5, 0, 0, time.UTC)
+
+	// The first result is only a baseline, even if it is today's fixing
+	st := pollState{}.observe(monday, now)
+	if !st.newest.Equal(monday) || !st.lastChange.IsZero() {
+		t.Errorf("first observe() = %+v, want newest %s and no change", st, monday)
+	}
+
+	// The same or an older publication is no change
+	for _, pubDate := range []time.Time{monday, monday.AddDate(0, 0, -1), {}} {
+		if got := st.observe(pubDate, now); got != st {
+			t.Errorf("observe(%s) = %+v, want %+v", pubDate, got, st)
+		}
+	}
+
+	// A newer publication is
+	st = st.observe(tuesday, now)
+	if !st.newest.Equal(tuesday) || !st.lastChange.Equal(now) {
+		t.Errorf("observe(newer) = %+v, want newest %s changed at %s", st, tuesday, now)
+	}
+}
@@ -1,12 +1,18 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/sirupsen/logrus"
 )
@@ -24,57 +30,108 @@ var maturityURLs = map[string]string{
 	"12M": euriborBaseURL + "/4/euribor-rate-12-months/",
 }
 
+// Maturity names as they appear in the page title
+var maturityNames = map[string]string{
+	"1W":  "1 week",
+	"1M":  "1 month",
+	"3M":  "3 months",
+	"6M":  "6 months",
+	"12M": "12 months",
+}
+
+// Extraction strategies, in the order they are tried
+const (
+	// StrategyTableClass reads the table with class "table_historiek"
+	StrategyTableClass = "table_historiek"
+	// StrategyHeaderMatch reads the first table whose header reads Date, Rate
+	StrategyHeaderMatch = "header_match"
+)
+
+// Strategies returns the extraction strategies in the order they are tried
+func Strategies() []string {
+	return []string{StrategyTableClass, StrategyHeaderMatch}
+}
+
+// ErrLayoutChanged is returned when the page no longer has the expected
+// structure, so that no rate is read from the wrong place
+var ErrLayoutChanged = errors.New("page layout changed")
+
 // EuriborData holds the scraped rate and publication date
 type EuriborData struct {
 	Rate            float64
 	PublicationDate time.Time
+	Strategy        string // Extraction strategy that found the rate table
 }
 
+// Publication dates older than these are rejected as implausible
+const (
+	// The newest row is at most a few business days old, even over the holidays
+	latestMaxAge = 31 * 24 * time.Hour
+	// The history table only shows recent fixings
+	historyMaxAge = 366 * 24 * time.Hour
+)
+
 // Scraper handles fetching Euribor rates from euribor-rates.eu
 type Scraper struct {
 	client *http.Client
 	log    *logrus.Logger
+	now    func() time.Time
 }
 
 // New creates a new scraper instance
 func New(log *logrus.Logger) *Scraper {
+	return NewWithClient(&http.Client{
+		Timeout: 30 * time.Second,
+	}, log)
+}
+
+// NewWithClient creates a new scraper instance using the given HTTP client
+func NewWithClient(client *http.Client, log *logrus.Logger) *Scraper {
 	return &Scraper{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		log: log,
+		client: client,
+		log:    log,
+		now:    time.Now,
 	}
 }
 
 // FetchRate scrapes Euribor rate from euribor-rates.eu
-func (s *Scraper) FetchRate(maturity string) (*EuriborData, error) {
-	url, exists := maturityURLs[maturity]
-	if !exists {
-		return nil, fmt.Errorf("invalid maturity: %s", maturity)
+func (s *Scraper) FetchRate(ctx context.Context, maturity string) (*EuriborData, error) {
+	doc, err := s.fetchDocument(ctx, maturity)
+	if err != nil {
+		return nil, err
 	}
 
-	s.log.WithFields(logrus.Fields{
-		"maturity": maturity,
-		"url":      url,
-	}).Debug("Fetching Euribor rate from web")
+	return s.latest(doc, maturity)
+}
 
-	// Fetch the page
-	resp, err := s.client.Get(url)
+// FetchRateAndHistory scrapes the latest rate and the historical table from a
+// single download of the page. Only a missing latest rate is an error; the
+// history is nil when it cannot be parsed.
+func (s *Scraper) FetchRateAndHistory(ctx context.Context, maturity string) (*EuriborData, []EuriborData, error) {
+	doc, err := s.fetchDocument(ctx, maturity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page: %w", err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	data, err := s.latest(doc, maturity)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Parse HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	history, err := s.extractHistory(doc, maturity)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		s.log.WithFields(logrus.Fields{
+			"maturity": maturity,
+			"error":    err,
+		}).Debug("No usable history on the rate page")
+		return data, nil, nil
 	}
 
+	return data, history, nil
+}
+
+// latest extracts the latest rate from the page for maturity
+func (s *Scraper) latest(doc *goquery.Document, maturity string) (*EuriborData, error) {
 	// Extract rate and date from the HTML table
 	data, err := s.extractData(doc, maturity)
 	if err != nil {
@@ -85,75 +142,263 @@ func (s *Scraper) FetchRate(maturity string) (*EuriborData, error) {
 		"maturity": maturity,
 		"rate":     data.Rate,
 		"date":     data.PublicationDate.Format("2006-01-02"),
+		"strategy": data.Strategy,
 	}).Info("Successfully scraped Euribor rate")
 
 	return data, nil
 }
 
+// FetchHistory scrapes every dated row of the historical table on
+// euribor-rates.eu, newest first
+func (s *Scraper) FetchHistory(ctx context.Context, maturity string) ([]EuriborData, error) {
+	doc, err := s.fetchDocument(ctx, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.extractHistory(doc, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"maturity": maturity,
+		"rows":     len(history),
+		"newest":   history[0].PublicationDate.Format("2006-01-02"),
+		"oldest":   history[len(history)-1].PublicationDate.Format("2006-01-02"),
+	}).Info("Successfully scraped Euribor history")
+
+	return history, nil
+}
+
+// fetchDocument downloads and parses the page for maturity
+func (s *Scraper) fetchDocument(ctx context.Context, maturity string) (*goquery.Document, error) {
+	url, exists := maturityURLs[maturity]
+	if !exists {
+		return nil, fmt.Errorf("invalid maturity: %s", maturity)
+	}
+
+	s.log.WithFields(logrus.Fields{
+		"maturity": maturity,
+		"url":      url,
+	}).Debug("Fetching Euribor rate from web")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Fetch the page
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fetcherr.Request(ctx, fmt.Errorf("failed to fetch page: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fetcherr.Status(resp.StatusCode)
+	}
+
+	// Parse HTML
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fetcherr.Errorf(fetcherr.ParseHTML, "failed to parse HTML: %w", err)
+	}
+
+	return doc, nil
+}
+
 // extractData parses the HTML document and extracts rate and date
 func (s *Scraper) extractData(doc *goquery.Document, maturity string) (*EuriborData, error) {
 	// The structure is typically:
+	// <html lang="en">
+	// <h1>Euribor 3 months</h1>
 	// <table class="table_historiek">
+	//   <thead>
+	//     <tr><th>Date</th><th>Rate</th></tr>
+	//   </thead>
 	//   <tbody>
 	//     <tr>
-	//       <td>12/13/2025</td>
+	//       <td>12/12/2025</td>
 	//       <td>2.524 %</td>
 	//     </tr>
 	//   </tbody>
 	// </table>
 
-	var data EuriborData
-	var dateStr, rateStr string
-	var found bool
-
-	// Strategy 1: Look for table with class "table_historiek"
-	doc.Find("table.table_historiek tbody tr").First().Each(func(i int, s *goquery.Selection) {
-		cells := s.Find("td")
-		if cells.Length() >= 2 {
-			dateStr = strings.TrimSpace(cells.Eq(0).Text())
-			rateStr = strings.TrimSpace(cells.Eq(1).Text())
-			found = true
-		}
-	})
+	rows, strategy, err := findRateTable(doc, maturity)
+	if err != nil {
+		return nil, err
+	}
 
-	// Strategy 2: If not found, try any table in main content
-	if !found {
-		doc.Find("table tr").First().Each(func(i int, s *goquery.Selection) {
-			cells := s.Find("td")
-			if cells.Length() >= 2 {
-				dateStr = strings.TrimSpace(cells.Eq(0).Text())
-				rateStr = strings.TrimSpace(cells.Eq(1).Text())
-				found = true
-			}
-		})
+	if rows.Length() == 0 {
+		return nil, layoutError("rate table has no rows")
 	}
 
-	if !found {
-		return nil, fmt.Errorf("could not find rate data in HTML")
+	cells := rows.First().Find("td")
+	if cells.Length() != 2 {
+		return nil, layoutError("first row of the rate table has %d cells, want 2", cells.Length())
 	}
+	dateStr := strings.TrimSpace(cells.Eq(0).Text())
+	rateStr := strings.TrimSpace(cells.Eq(1).Text())
+
+	var data EuriborData
+	data.Strategy = strategy
 
 	// Parse rate
 	rate, err := parseRate(rateStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse rate '%s': %w", rateStr, err)
+		return nil, fetcherr.Errorf(fetcherr.ParseRate, "failed to parse rate '%s': %w", rateStr, err)
 	}
 	data.Rate = rate
 
 	// Parse date
-	pubDate, err := parseDate(dateStr)
+	pubDate, err := s.parsePublicationDate(dateStr, pageDateOrder(doc), latestMaxAge)
 	if err != nil {
+		return nil, fetcherr.New(fetcherr.ParseDate, err)
+	}
+	data.PublicationDate = pubDate
+
+	if strategy != StrategyTableClass {
 		s.log.WithFields(logrus.Fields{
 			"maturity": maturity,
-			"date_str": dateStr,
-			"error":    err,
-		}).Warn("Failed to parse publication date, using current time")
-		pubDate = time.Now()
+			"strategy": strategy,
+		}).Warn("Rate table found by fallback strategy, page layout may have changed")
 	}
-	data.PublicationDate = pubDate
 
 	return &data, nil
 }
 
+// findRateTable validates the page structure and returns the data rows of the
+// rate table for maturity together with the strategy that found it. It fails
+// rather than guess when the page is not the maturity's page or no table has
+// the expected shape.
+func findRateTable(doc *goquery.Document, maturity string) (*goquery.Selection, string, error) {
+	name, exists := maturityNames[maturity]
+	if !exists {
+		return nil, "", fmt.Errorf("invalid maturity: %s", maturity)
+	}
+
+	heading := strings.ToLower(doc.Find("title, h1").Text())
+	if !strings.Contains(heading, "euribor") || !strings.Contains(heading, name) {
+		return nil, "", layoutError("page title does not mention Euribor %s", name)
+	}
+
+	// The class names the table; a header row, if any, must still match
+	if table := doc.Find("table.table_historiek").First(); table.Length() > 0 {
+		header := headerCells(table)
+		if len(header) > 0 && !validHeader(header) {
+			return nil, "", layoutError("rate table header is %q, want Date, Rate", header)
+		}
+		return dataRows(table), StrategyTableClass, nil
+	}
+
+	// Without the class only a table with the expected header is trusted
+	var rows *goquery.Selection
+	doc.Find("table").EachWithBreak(func(i int, table *goquery.Selection) bool {
+		if validHeader(headerCells(table)) {
+			rows = dataRows(table)
+			return false
+		}
+		return true
+	})
+	if rows == nil {
+		return nil, "", layoutError("no table with a Date, Rate header")
+	}
+
+	return rows, StrategyHeaderMatch, nil
+}
+
+// headerCells returns the trimmed header cell texts of table
+func headerCells(table *goquery.Selection) []string {
+	var header []string
+	table.Find("tr").First().Find("th").Each(func(i int, th *goquery.Selection) {
+		header = append(header, strings.TrimSpace(th.Text()))
+	})
+	return header
+}
+
+// validHeader reports whether header has a date column followed by a rate column
+func validHeader(header []string) bool {
+	return len(header) == 2 &&
+		strings.Contains(strings.ToLower(header[0]), "date") &&
+		strings.Contains(strings.ToLower(header[1]), "rate")
+}
+
+// dataRows returns the rows of table that hold data cells
+func dataRows(table *goquery.Selection) *goquery.Selection {
+	return table.Find("tr").FilterFunction(func(i int, row *goquery.Selection) bool {
+		return row.Find("td").Length() > 0
+	})
+}
+
+func layoutError(format string, args ...any) error {
+	return fetcherr.Errorf(fetcherr.ParseHTML, "%w: %s", ErrLayoutChanged, fmt.Sprintf(format, args...))
+}
+
+// extractHistory parses every row of the historical rate table. Rows whose
+// rate or date cannot be parsed are skipped, since a row without a reliable
+// date cannot be placed in history.
+func (s *Scraper) extractHistory(doc *goquery.Document, maturity string) ([]EuriborData, error) {
+	rows, _, err := findRateTable(doc, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	order := pageDateOrder(doc)
+	seen := make(map[time.Time]bool)
+	var history []EuriborData
+
+	rows.Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() != 2 {
+			return
+		}
+
+		dateStr := strings.TrimSpace(cells.Eq(0).Text())
+		rateStr := strings.TrimSpace(cells.Eq(1).Text())
+
+		rate, err := parseRate(rateStr)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"maturity": maturity,
+				"rate_str": rateStr,
+				"error":    err,
+			}).Debug("Skipping history row with unparseable rate")
+			return
+		}
+
+		pubDate, err := s.parsePublicationDate(dateStr, order, historyMaxAge)
+		if err != nil {
+			s.log.WithFields(logrus.Fields{
+				"maturity": maturity,
+				"date_str": dateStr,
+				"error":    err,
+			}).Warn("Skipping history row with unparseable date")
+			return
+		}
+
+		if seen[pubDate] {
+			return
+		}
+		seen[pubDate] = true
+
+		history = append(history, EuriborData{
+			Rate:            rate,
+			PublicationDate: pubDate,
+		})
+	})
+
+	if len(history) == 0 {
+		return nil, fetcherr.Errorf(fetcherr.ParseHTML, "could not find rate history in HTML")
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].PublicationDate.After(history[j].PublicationDate)
+	})
+
+	return history, nil
+}
+
 // parseRate extracts the numeric rate from strings like "2.524 %", "2,524%", etc.
 func parseRate(s string) (float64, error) {
 	// Remove percentage sign and whitespace
@@ -187,17 +432,43 @@ func parseRate(s string) (float64, error) {
 	return rate, nil
 }
 
-// parseDate tries multiple date formats commonly used in EU
-func parseDate(s string) (time.Time, error) {
-	s = strings.TrimSpace(s)
+// dateOrder is the order of day and month in numeric dates like 03/04/2025
+type dateOrder int
 
-	// Try multiple common date formats
-	formats := []string{
-		"01/02/2006",      // MM/DD/YYYY (US format)
-		"02/01/2006",      // DD/MM/YYYY (EU format)
+const (
+	orderUnknown dateOrder = iota
+	orderMonthFirst
+	orderDayFirst
+)
+
+// Locales that write numeric dates month first; all others are day first
+var monthFirstLocales = map[string]bool{
+	"en":    true,
+	"en-us": true,
+}
+
+// pageDateOrder derives the date order from the page language
+func pageDateOrder(doc *goquery.Document) dateOrder {
+	locale := doc.Find("html").AttrOr("lang", "")
+	if locale == "" {
+		locale = doc.Find(`meta[property="og:locale"]`).AttrOr("content", "")
+	}
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+
+	switch {
+	case locale == "":
+		return orderUnknown
+	case monthFirstLocales[locale]:
+		return orderMonthFirst
+	default:
+		return orderDayFirst
+	}
+}
+
+var (
+	// Formats that read the same in every locale
+	unambiguousDateFormats = []string{
 		"2006-01-02",      // YYYY-MM-DD (ISO)
-		"02-01-2006",      // DD-MM-YYYY
-		"01-02-2006",      // MM-DD-YYYY
 		"2.1.2006",        // D.M.YYYY (short EU)
 		"02.01.2006",      // DD.MM.YYYY (EU with dots)
 		"Jan 2, 2006",     // Month name format
@@ -205,18 +476,88 @@ func parseDate(s string) (time.Time, error) {
 		"January 2, 2006", // Full month name
 		"2 January 2006",  // EU full month name
 	}
+	monthFirstDateFormats = []string{
+		"01/02/2006", // MM/DD/YYYY (US format)
+		"01-02-2006", // MM-DD-YYYY
+	}
+	dayFirstDateFormats = []string{
+		"02/01/2006", // DD/MM/YYYY (EU format)
+		"02-01-2006", // DD-MM-YYYY
+	}
+)
+
+// parseDate returns every date s can be read as, those in the page's date
+// order first
+func parseDate(s string, order dateOrder) ([]time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	formats := append([]string(nil), unambiguousDateFormats...)
+	if order == orderDayFirst {
+		formats = append(formats, dayFirstDateFormats...)
+		formats = append(formats, monthFirstDateFormats...)
+	} else {
+		formats = append(formats, monthFirstDateFormats...)
+		formats = append(formats, dayFirstDateFormats...)
+	}
 
+	var candidates []time.Time
 	var lastErr error
 	for _, format := range formats {
 		t, err := time.Parse(format, s)
-		if err == nil {
-			return t, nil
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !slices.ContainsFunc(candidates, t.Equal) {
+			candidates = append(candidates, t)
 		}
-		lastErr = err
 	}
 
-	// If all formats fail, return the last error
-	return time.Time{}, fmt.Errorf("could not parse date '%s': %w", s, lastErr)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("could not parse date '%s': %w", s, lastErr)
+	}
+	return candidates, nil
+}
+
+// parsePublicationDate reads s as the date of a fixing. Only a TARGET business
+// day that is not in the future and at most maxAge old is accepted. If the
+// page's date order is unknown and s reads as two such days, s is ambiguous
+// and rejected rather than guessed.
+func (s *Scraper) parsePublicationDate(str string, order dateOrder, maxAge time.Duration) (time.Time, error) {
+	candidates, err := parseDate(str, order)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Fixings are dated in Brussels
+	year, month, day := s.now().In(publicationLocation).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	var plausible []time.Time
+	for _, c := range candidates {
+		if !c.After(today) && today.Sub(c) <= maxAge && calendar.IsBusinessDay(c) {
+			plausible = append(plausible, c)
+		}
+	}
+
+	switch {
+	case len(plausible) == 0:
+		return time.Time{}, fmt.Errorf("date '%s' is not a TARGET business day within %s before today", str, maxAge)
+	case len(plausible) > 1 && order == orderUnknown:
+		return time.Time{}, fmt.Errorf("date '%s' is ambiguous without the page locale", str)
+	default:
+		return plausible[0], nil
+	}
+}
+
+var publicationLocation = mustLoadLocation(calendar.DefaultLocation)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
 }
 
 // GetSupportedMaturities returns list of supported maturities
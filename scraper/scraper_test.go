@@ -1,8 +1,14 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/GoGstickGo/euribor-exporter/fetcherr"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/sirupsen/logrus"
 )
 
@@ -56,7 +62,7 @@ func TestParseDate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			_, err := parseDate(tt.input)
+			_, err := parseDate(tt.input, orderUnknown)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
 			}
@@ -64,6 +70,82 @@ func TestParseDate(t *testing.T) {
 	}
 }
 
+// newTestScraper returns a scraper whose clock reads now
+func newTestScraper(now time.Time) *Scraper {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	s := New(log)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestParsePublicationDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		order   dateOrder
+		maxAge  time.Duration
+		want    string
+		wantErr bool
+	}{
+		{"month first locale", "12/03/2025", orderMonthFirst, latestMaxAge, "2025-12-03", false},
+		{"day first locale", "03/12/2025", orderDayFirst, latestMaxAge, "2025-12-03", false},
+		{"single reading without locale", "11/28/2025", orderUnknown, latestMaxAge, "2025-11-28", false},
+		{"old reading ruled out without locale", "12/03/2025", orderUnknown, latestMaxAge, "2025-12-03", false},
+		{"ambiguous without locale", "12/11/2025", orderUnknown, latestMaxAge, "", true},
+		{"both readings plausible, month first", "12/11/2025", orderMonthFirst, latestMaxAge, "2025-12-11", false},
+		{"both readings plausible, day first", "12/11/2025", orderDayFirst, latestMaxAge, "2025-11-12", false},
+		{"wrong locale corrected by sanity check", "11/28/2025", orderDayFirst, latestMaxAge, "2025-11-28", false},
+		{"weekend", "12/06/2025", orderMonthFirst, latestMaxAge, "", true},
+		{"future date", "12/15/2025", orderMonthFirst, latestMaxAge, "", true},
+		{"TARGET holiday", "2025-05-01", orderUnknown, historyMaxAge, "", true},
+		{"too old", "2025-06-02", orderUnknown, latestMaxAge, "", true},
+		{"ISO", "2025-12-12", orderDayFirst, latestMaxAge, "2025-12-12", false},
+		{"garbage", "yesterday", orderMonthFirst, latestMaxAge, "", true},
+	}
+
+	// Friday evening in Brussels
+	s := newTestScraper(time.Date(2025, 12, 12, 18, 0, 0, 0, time.UTC))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.parsePublicationDate(tt.input, tt.order, tt.maxAge)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePublicationDate(%q) = %s, want error", tt.input, got.Format("2006-01-02"))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePublicationDate(%q) unexpected error: %v", tt.input, err)
+			}
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("parsePublicationDate(%q) = %s, want %s", tt.input, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestPageDateOrder(t *testing.T) {
+	tests := map[string]dateOrder{
+		`<html lang="en">`:    orderMonthFirst,
+		`<html lang="en-US">`: orderMonthFirst,
+		`<html lang="en-GB">`: orderDayFirst,
+		`<html lang="nl">`:    orderDayFirst,
+		`<html><head><meta property="og:locale" content="en_US"></head>`: orderMonthFirst,
+		`<html>`: orderUnknown,
+	}
+
+	for html, want := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html + "<body></body></html>"))
+		if err != nil {
+			t.Fatalf("failed to parse HTML: %v", err)
+		}
+		if got := pageDateOrder(doc); got != want {
+			t.Errorf("pageDateOrder(%s) = %d, want %d", html, got, want)
+		}
+	}
+}
+
 func TestGetSupportedMaturities(t *testing.T) {
 	maturities := GetSupportedMaturities()
 
@@ -88,6 +170,177 @@ func TestGetSupportedMaturities(t *testing.T) {
 	}
 }
 
+func TestExtractHistory(t *testing.T) {
+	html := `<html lang="en"><body>
+<h1>Euribor 3 months</h1>
+<table class="table_historiek">
+  <thead><tr><th>Date</th><th>Rate</th></tr></thead>
+  <tbody>
+    <tr><td>12/12/2025</td><td>2.072 %</td></tr>
+    <tr><td>12/15/2025</td><td>2.081 %</td></tr>
+    <tr><td>12/11/2025</td><td>2.065 %</td></tr>
+    <tr><td>12/11/2025</td><td>2.065 %</td></tr>
+    <tr><td>not a date</td><td>2.050 %</td></tr>
+    <tr><td>12/10/2025</td><td>n/a</td></tr>
+  </tbody>
+</table>
+</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+
+	s := newTestScraper(time.Date(2025, 12, 16, 12, 0, 0, 0, time.UTC))
+
+	history, err := s.extractHistory(doc, "3M")
+	if err != nil {
+		t.Fatalf("extractHistory() unexpected error: %v", err)
+	}
+
+	want := []struct {
+		date string
+		rate float64
+	}{
+		{"2025-12-15", 2.081},
+		{"2025-12-12", 2.072},
+		{"2025-12-11", 2.065},
+	}
+
+	if len(history) != len(want) {
+		t.Fatalf("extractHistory() returned %d rows, want %d", len(history), len(want))
+	}
+	for i, w := range want {
+		wantDate, _ := time.Parse("2006-01-02", w.date)
+		if !history[i].PublicationDate.Equal(wantDate) || history[i].Rate != w.rate {
+			t.Errorf("row %d = %s %v, want %s %v", i,
+				history[i].PublicationDate.Format("2006-01-02"), history[i].Rate, w.date, w.rate)
+		}
+	}
+}
+
+func TestExtractData(t *testing.T) {
+	const row = `<tr><td>12/15/2025</td><td>2.081 %</td></tr>`
+
+	tests := []struct {
+		name     string
+		html     string
+		strategy string
+		wantErr  bool
+	}{
+		{
+			name:     "table class without header",
+			html:     `<h1>Euribor 3 months</h1><table class="table_historiek"><tbody>` + row + `</tbody></table>`,
+			strategy: StrategyTableClass,
+		},
+		{
+			name: "table class with header",
+			html: `<title>Euribor 3 months - current rates</title>
+<table class="table_historiek"><thead><tr><th>Date</th><th>Rate</th></tr></thead><tbody>` + row + `</tbody></table>`,
+			strategy: StrategyTableClass,
+		},
+		{
+			name: "table matched by header",
+			html: `<h1>Euribor 3 months</h1>
+<table><tr><td>Sponsored</td><td>9.99 %</td></tr></table>
+<table class="rates"><tr><th>Date</th><th>Euribor rate</th></tr>` + row + `</table>`,
+			strategy: StrategyHeaderMatch,
+		},
+		{
+			name:    "any table without header",
+			html:    `<h1>Euribor 3 months</h1><table>` + row + `</table>`,
+			wantErr: true,
+		},
+		{
+			name:    "page of another maturity",
+			html:    `<h1>Euribor 12 months</h1><table class="table_historiek"><tbody>` + row + `</tbody></table>`,
+			wantErr: true,
+		},
+		{
+			name: "unexpected header",
+			html: `<h1>Euribor 3 months</h1>
+<table class="table_historiek"><tr><th>Date</th><th>Change</th></tr>` + row + `</table>`,
+			wantErr: true,
+		},
+		{
+			name: "unexpected column count",
+			html: `<h1>Euribor 3 months</h1>
+<table class="table_historiek"><tbody><tr><td>12/15/2025</td><td>+0.01</td><td>2.081 %</td></tr></tbody></table>`,
+			wantErr: true,
+		},
+		{
+			name:    "empty table",
+			html:    `<h1>Euribor 3 months</h1><table class="table_historiek"><tbody></tbody></table>`,
+			wantErr: true,
+		},
+	}
+
+	s := newTestScraper(time.Date(2025, 12, 16, 12, 0, 0, 0, time.UTC))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html lang="en"><body>` + tt.html + "</body></html>"))
+			if err != nil {
+				t.Fatalf("failed to parse HTML: %v", err)
+			}
+
+			data, err := s.extractData(doc, "3M")
+			if tt.wantErr {
+				if !errors.Is(err, ErrLayoutChanged) {
+					t.Errorf("extractData() error = %v, want ErrLayoutChanged", err)
+				}
+				if got := fetcherr.ReasonOf(err); got != fetcherr.ParseHTML {
+					t.Errorf("extractData() error reason = %q, want %q", got, fetcherr.ParseHTML)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractData() unexpected error: %v", err)
+			}
+			if data.Rate != 2.081 || data.Strategy != tt.strategy {
+				t.Errorf("extractData() = %v via %s, want 2.081 via %s", data.Rate, data.Strategy, tt.strategy)
+			}
+		})
+	}
+}
+
+func TestExtractDataBadDate(t *testing.T) {
+	html := `<html lang="en"><body><h1>Euribor 3 months</h1>
+<table class="table_historiek"><tbody><tr><td>12/13/2025</td><td>2.081 %</td></tr></tbody></table>
+</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+
+	// A Saturday is never a fixing date, so no date is made up for it
+	s := newTestScraper(time.Date(2025, 12, 16, 12, 0, 0, 0, time.UTC))
+	data, err := s.extractData(doc, "3M")
+	if err == nil {
+		t.Fatalf("extractData() = %v, want error", data.PublicationDate)
+	}
+	if got := fetcherr.ReasonOf(err); got != fetcherr.ParseDate {
+		t.Errorf("extractData() error reason = %q, want %q", got, fetcherr.ParseDate)
+	}
+}
+
+func TestExtractHistoryNoTable(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body><p>Maintenance</p></body></html>"))
+	if err != nil {
+		t.Fatalf("failed to parse HTML: %v", err)
+	}
+
+	s := New(logrus.New())
+	_, err = s.extractHistory(doc, "3M")
+	if err == nil {
+		t.Fatal("extractHistory() expected error for page without table, got nil")
+	}
+	if got := fetcherr.ReasonOf(err); got != fetcherr.ParseHTML {
+		t.Errorf("extractHistory() error reason = %q, want %q", got, fetcherr.ParseHTML)
+	}
+}
+
 // TestFetchRate_Live is an integration test that actually hits the website
 // Run with: go test -tags=integration
 func TestFetchRate_Live(t *testing.T) {
@@ -101,7 +354,7 @@ func TestFetchRate_Live(t *testing.T) {
 	s := New(log)
 
 	// Test fetching 3M rate
-	data, err := s.FetchRate("3M")
+	data, err := s.FetchRate(context.Background(), "3M")
 	if err != nil {
 		t.Fatalf("Failed to fetch 3M rate: %v", err)
 	}
@@ -130,6 +383,6 @@ func BenchmarkParseRate(b *testing.B) {
 func BenchmarkParseDate(b *testing.B) {
 	input := "12/13/2025"
 	for i := 0; i < b.N; i++ {
-		_, _ = parseDate(input)
+		_, _ = parseDate(input, orderMonthFirst)
 	}
 }
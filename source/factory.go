@@ -0,0 +1,67 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Settings configure a source created by a Factory. They come from the
+// sources.custom.<name> section of the configuration.
+type Settings struct {
+	URL     string            // sources.custom.<name>.url
+	Options map[string]string // sources.custom.<name>.options, free-form
+
+	// Client sends the configured User-Agent, retries transient failures and
+	// stops calling the upstream while the source's circuit is open
+	Client *http.Client
+}
+
+// Factory creates a source from its settings. The source's Name must be the
+// name the factory was registered under.
+type Factory func(Settings) (RateSource, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterFactory makes a source available to the configuration under name.
+// It is meant to be called from the init function of the package
+// implementing the source and panics if name is empty or already taken.
+func RegisterFactory(name string, factory Factory) {
+	if name == "" {
+		panic("source: factory name must not be empty")
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("source: factory already registered: %s", name))
+	}
+	factories[name] = factory
+}
+
+// LookupFactory returns the factory registered under name
+func LookupFactory(name string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, exists := factories[name]
+	return factory, exists
+}
+
+// FactoryNames returns the names of all registered factories ordered alphabetically
+func FactoryNames() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
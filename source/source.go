@@ -0,0 +1,117 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Publication frequencies reported in Rate.Frequency
+const (
+	Daily   = "daily"   // A fixing per TARGET business day
+	Monthly = "monthly" // Monthly averages
+)
+
+// Rate holds a single Euribor fixing returned by a RateSource
+type Rate struct {
+	Rate            float64
+	PublicationDate time.Time
+	Frequency       string // Daily, Monthly or another source-specific frequency
+	Strategy        string // How the rate was extracted, for sources with fallbacks; empty otherwise
+}
+
+// RateSource is an upstream that can provide Euribor rates
+type RateSource interface {
+	// Name returns a short unique identifier used in logs and metric labels
+	Name() string
+
+	// Maturities returns the maturities this source is able to fetch
+	Maturities() []string
+
+	// Fetch retrieves the latest rate for the given maturity
+	Fetch(ctx context.Context, maturity string) (*Rate, error)
+}
+
+// HistorySource is implemented by sources that can also return the recent
+// fixings preceding the latest one, so gaps after downtime can be filled
+type HistorySource interface {
+	RateSource
+
+	// FetchHistory retrieves all recent rates for the given maturity, newest first
+	FetchHistory(ctx context.Context, maturity string) ([]Rate, error)
+}
+
+// Registry holds the set of rate sources the exporter polls
+type Registry struct {
+	mu      sync.RWMutex
+	sources map[string]RateSource
+}
+
+// NewRegistry creates an empty source registry
+func NewRegistry() *Registry {
+	return &Registry{
+		sources: make(map[string]RateSource),
+	}
+}
+
+// Register adds a source to the registry. Source names must be unique.
+func (r *Registry) Register(src RateSource) error {
+	name := src.Name()
+	if name == "" {
+		return fmt.Errorf("source name must not be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sources[name]; exists {
+		return fmt.Errorf("source already registered: %s", name)
+	}
+	r.sources[name] = src
+
+	return nil
+}
+
+// MustRegister is like Register but panics on error
+func (r *Registry) MustRegister(src RateSource) {
+	if err := r.Register(src); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the source registered under name
+func (r *Registry) Get(name string) (RateSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	src, exists := r.sources[name]
+	return src, exists
+}
+
+// Sources returns all registered sources ordered by name
+func (r *Registry) Sources() []RateSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sources := make([]RateSource, 0, len(r.sources))
+	for _, src := range r.sources {
+		sources = append(sources, src)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].Name() < sources[j].Name()
+	})
+
+	return sources
+}
+
+// Names returns the names of all registered sources ordered alphabetically
+func (r *Registry) Names() []string {
+	sources := r.Sources()
+	names := make([]string, 0, len(sources))
+	for _, src := range sources {
+		names = append(names, src.Name())
+	}
+	return names
+}
@@ -0,0 +1,70 @@
+package source
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeSource struct {
+	name string
+}
+
+func (f *fakeSource) Name() string         { return f.name }
+func (f *fakeSource) Maturities() []string { return []string{"3M"} }
+func (f *fakeSource) Fetch(ctx context.Context, maturity string) (*Rate, error) {
+	return &Rate{Rate: 2.5}, nil
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(&fakeSource{name: "b"}); err != nil {
+		t.Fatalf("Register(b) unexpected error: %v", err)
+	}
+	if err := r.Register(&fakeSource{name: "a"}); err != nil {
+		t.Fatalf("Register(a) unexpected error: %v", err)
+	}
+	if err := r.Register(&fakeSource{name: "a"}); err == nil {
+		t.Error("Register(a) twice: expected error, got nil")
+	}
+	if err := r.Register(&fakeSource{name: ""}); err == nil {
+		t.Error("Register with empty name: expected error, got nil")
+	}
+
+	names := r.Names()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Names() = %v, want [a b]", names)
+	}
+
+	if _, ok := r.Get("b"); !ok {
+		t.Error("Get(b) not found")
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error("Get(missing) unexpectedly found")
+	}
+}
+
+func TestRegisterFactory(t *testing.T) {
+	RegisterFactory("test-factory", func(s Settings) (RateSource, error) {
+		return &fakeSource{name: "test-factory"}, nil
+	})
+
+	factory, ok := LookupFactory("test-factory")
+	if !ok {
+		t.Fatal("LookupFactory(test-factory) not found")
+	}
+	src, err := factory(Settings{})
+	if err != nil || src.Name() != "test-factory" {
+		t.Errorf("factory() = %v, %v, want source test-factory", src, err)
+	}
+	if _, ok := LookupFactory("missing"); ok {
+		t.Error("LookupFactory(missing) unexpectedly found")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterFactory(test-factory) twice: expected panic")
+		}
+	}()
+	RegisterFactory("test-factory", nil)
+}
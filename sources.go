@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/breaker"
+	"github.com/GoGstickGo/euribor-exporter/config"
+	"github.com/GoGstickGo/euribor-exporter/ecb"
+	"github.com/GoGstickGo/euribor-exporter/retry"
+	"github.com/GoGstickGo/euribor-exporter/scraper"
+	"github.com/GoGstickGo/euribor-exporter/source"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the built-in rate sources
+const (
+	sourceDaily = "daily-scraper"
+	sourceECB   = "ecb"
+)
+
+// buildSources creates a registry holding the sources enabled in cfg
+func buildSources(cfg *config.Config) (*source.Registry, error) {
+	sources := source.NewRegistry()
+
+	if cfg.Sources.Daily.Enabled {
+		client := newHTTPClient(cfg.Sources.Daily.Timeout, cfg.HTTP.UserAgent, retryPolicy(cfg), sourceBreaker(sourceDaily, breakerPolicy(cfg)))
+		if err := sources.Register(newScraperSource(client)); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Sources.ECB.Enabled {
+		client := newHTTPClient(cfg.Sources.ECB.Timeout, cfg.HTTP.UserAgent, retryPolicy(cfg), sourceBreaker(sourceECB, breakerPolicy(cfg)))
+		if err := sources.Register(newECBSource(cfg.Sources.ECB.URL, client)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range cfg.Sources.CustomNames() {
+		custom := cfg.Sources.Custom[name]
+		if !custom.Enabled {
+			continue
+		}
+
+		if name == sourceDaily || name == sourceECB {
+			return nil, fmt.Errorf("sources.custom.%s: name is taken by a built-in source", name)
+		}
+
+		factory, exists := source.LookupFactory(name)
+		if !exists {
+			return nil, fmt.Errorf("sources.custom.%s: no source registered under this name, registered: %v", name, source.FactoryNames())
+		}
+
+		client := newHTTPClient(custom.Timeout, cfg.HTTP.UserAgent, retryPolicy(cfg), sourceBreaker(name, breakerPolicy(cfg)))
+		src, err := factory(source.Settings{
+			URL:     custom.URL,
+			Options: custom.Options,
+			Client:  client,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("sources.custom.%s: %w", name, err)
+		}
+		if src.Name() != name {
+			return nil, fmt.Errorf("sources.custom.%s: factory created source %q", name, src.Name())
+		}
+		if err := sources.Register(src); err != nil {
+			return nil, err
+		}
+	}
+
+	return sources, nil
+}
+
+// exporterConfigFromConfig returns the exporter settings for cfg polling sources
+func exporterConfigFromConfig(cfg *config.Config, sources *source.Registry) exporterConfig {
+	return exporterConfig{
+		sources:     sources,
+		options:     sourceOptionsFromConfig(cfg),
+		maturities:  cfg.Maturities,
+		concurrency: cfg.Concurrency,
+		calendar:    publicationCalendarFromConfig(cfg),
+		seriesTTL:   cfg.Metrics.SeriesTTL,
+		legacyNames: cfg.Metrics.LegacyNames,
+		validation: validationPolicy{
+			minRate:         cfg.Validation.MinRate,
+			maxRate:         cfg.Validation.MaxRate,
+			maxJumpBP:       cfg.Validation.MaxJumpBP,
+			maxDivergenceBP: cfg.Validation.MaxDivergenceBP,
+		},
+		loans: loansFromConfig(cfg.Loans),
+	}
+}
+
+// sourceOptionsFromConfig returns the configured scheduling options of each
+// source by source name
+func sourceOptionsFromConfig(cfg *config.Config) map[string]sourceOptions {
+	options := map[string]sourceOptions{
+		sourceDaily: sourceOptionsFor(cfg, cfg.Sources.Daily),
+		sourceECB:   sourceOptionsFor(cfg, cfg.Sources.ECB),
+	}
+	for name, custom := range cfg.Sources.Custom {
+		options[name] = sourceOptionsFor(cfg, custom)
+	}
+	return options
+}
+
+// sourceOptionsFor returns the scheduling options of a single source
+func sourceOptionsFor(cfg *config.Config, src config.Source) sourceOptions {
+	return sourceOptions{
+		interval: src.Interval,
+		timeout:  src.Timeout,
+		schedule: pollScheduleFromConfig(cfg, src.Schedule),
+	}
+}
+
+// publicationCalendarFromConfig returns the configured publication calendar
+func publicationCalendarFromConfig(cfg *config.Config) publicationCalendar {
+	publication, _ := cfg.Calendar.Publication() // Validated by loadConfig
+	return publicationCalendar{
+		publication: publication,
+		lag:         cfg.Calendar.PublicationLag,
+	}
+}
+
+// pollScheduleFromConfig returns the calendar schedule for sources configured
+// with it, or nil for interval polling
+func pollScheduleFromConfig(cfg *config.Config, schedule string) *pollSchedule {
+	if schedule != config.ScheduleCalendar {
+		return nil
+	}
+
+	publication, _ := cfg.Calendar.Publication() // Validated by loadConfig
+	return &pollSchedule{
+		publication:    publication,
+		window:         cfg.Calendar.Window,
+		windowInterval: cfg.Calendar.WindowInterval,
+	}
+}
+
+// checkMaturities verifies that every configured maturity is served by at least one source
+func checkMaturities(sources *source.Registry, maturities []string) error {
+	supported := make(map[string]bool)
+	for _, src := range sources.Sources() {
+		for _, m := range src.Maturities() {
+			supported[m] = true
+		}
+	}
+
+	var errs []error
+	for _, m := range maturities {
+		if !supported[m] {
+			errs = append(errs, fmt.Errorf("maturity %q is not supported by any enabled source", m))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// retryPolicy converts the retry configuration into a retry.Policy
+func retryPolicy(cfg *config.Config) retry.Policy {
+	return retry.Policy{
+		MaxAttempts:          cfg.Retry.MaxAttempts,
+		BaseDelay:            cfg.Retry.BaseDelay,
+		MaxDelay:             cfg.Retry.MaxDelay,
+		Jitter:               cfg.Retry.Jitter,
+		RetryableStatusCodes: cfg.Retry.RetryableStatusCodes,
+	}
+}
+
+// breakerPolicy converts the circuit breaker configuration into a breaker.Policy
+func breakerPolicy(cfg *config.Config) breaker.Policy {
+	return breaker.Policy{
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		CoolDown:         cfg.CircuitBreaker.CoolDown,
+	}
+}
+
+// Circuit breakers by source name. They outlive the sources so that a
+// configuration reload does not close a circuit on an upstream that is down.
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*breaker.Breaker)
+)
+
+// sourceBreaker returns the circuit breaker of the named source, creating it
+// with policy on first use. The policy of an existing breaker is left alone
+// until setBreakerPolicy is called for a configuration that passed validation.
+func sourceBreaker(name string, policy breaker.Policy) *breaker.Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	if b, exists := breakers[name]; exists {
+		return b
+	}
+
+	b := breaker.New(policy, func(from, to breaker.State) {
+		euriborSourceCircuitState.WithLabelValues(name).Set(float64(to))
+		log.WithFields(logrus.Fields{
+			"source": name,
+			"from":   from.String(),
+			"to":     to.String(),
+		}).Warn("Circuit breaker state changed")
+	})
+	euriborSourceCircuitState.WithLabelValues(name).Set(float64(breaker.Closed))
+	breakers[name] = b
+
+	return b
+}
+
+// setBreakerPolicy applies policy to the circuit breakers of all sources
+func setBreakerPolicy(policy breaker.Policy) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	for _, b := range breakers {
+		b.SetPolicy(policy)
+	}
+}
+
+// newHTTPClient creates an HTTP client that sends userAgent, retries
+// transient failures and stops calling an upstream whose circuit is open.
+// timeout bounds all attempts of a request together.
+func newHTTPClient(timeout time.Duration, userAgent string, policy retry.Policy, b *breaker.Breaker) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: breaker.NewTransport(b, retry.NewTransport(policy, &userAgentTransport{
+			userAgent: userAgent,
+			next:      http.DefaultTransport,
+		})),
+	}
+}
+
+// userAgentTransport sets the User-Agent header on every outgoing request
+type userAgentTransport struct {
+	userAgent string
+	next      http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// scraperSource adapts the euribor-rates.eu web scraper to source.RateSource.
+// The rate page also carries the recent history, so Fetch keeps the history
+// it parsed for FetchHistory calls made during the same fetch instead of
+// downloading the page again.
+type scraperSource struct {
+	scraper *scraper.Scraper
+
+	mu      sync.Mutex
+	history map[string]fetchedHistory // By maturity
+}
+
+// fetchedHistory is the history parsed by a Fetch, valid for the context the
+// Fetch was called with
+type fetchedHistory struct {
+	done  <-chan struct{}
+	rates []source.Rate
+}
+
+func newScraperSource(client *http.Client) *scraperSource {
+	return &scraperSource{
+		scraper: scraper.NewWithClient(client, log),
+		history: make(map[string]fetchedHistory),
+	}
+}
+
+func (s *scraperSource) Name() string {
+	return sourceDaily
+}
+
+func (s *scraperSource) Maturities() []string {
+	maturities := scraper.GetSupportedMaturities()
+	sort.Strings(maturities)
+	return maturities
+}
+
+// Fetch fetches the Euribor rate from web scraper (daily data)
+func (s *scraperSource) Fetch(ctx context.Context, maturity string) (*source.Rate, error) {
+	data, history, err := s.scraper.FetchRateAndHistory(ctx, maturity)
+
+	// A context that is never done cannot tell one fetch from the next
+	s.mu.Lock()
+	if err == nil && history != nil && ctx.Done() != nil {
+		s.history[maturity] = fetchedHistory{done: ctx.Done(), rates: dailyRates(history)}
+	} else {
+		delete(s.history, maturity)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &source.Rate{
+		Rate:            data.Rate,
+		PublicationDate: data.PublicationDate,
+		Frequency:       source.Daily,
+		Strategy:        data.Strategy,
+	}, nil
+}
+
+// FetchHistory fetches the recent daily history shown on euribor-rates.eu,
+// reusing the page of a Fetch made with the same context
+func (s *scraperSource) FetchHistory(ctx context.Context, maturity string) ([]source.Rate, error) {
+	s.mu.Lock()
+	fetched, ok := s.history[maturity]
+	s.mu.Unlock()
+	if ok && ctx.Done() != nil && fetched.done == ctx.Done() {
+		return fetched.rates, nil
+	}
+
+	history, err := s.scraper.FetchHistory(ctx, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	return dailyRates(history), nil
+}
+
+// dailyRates converts scraped history rows to daily rates
+func dailyRates(history []scraper.EuriborData) []source.Rate {
+	rates := make([]source.Rate, 0, len(history))
+	for _, data := range history {
+		rates = append(rates, source.Rate{
+			Rate:            data.Rate,
+			PublicationDate: data.PublicationDate,
+			Frequency:       source.Daily,
+		})
+	}
+	return rates
+}
+
+// ecbSource adapts the ECB SDMX client to source.RateSource (monthly data)
+type ecbSource struct {
+	client *ecb.Client
+}
+
+func newECBSource(baseURL string, client *http.Client) *ecbSource {
+	return &ecbSource{
+		client: ecb.NewClient(baseURL, client, log),
+	}
+}
+
+func (s *ecbSource) Name() string {
+	return sourceECB
+}
+
+func (s *ecbSource) Maturities() []string {
+	return ecb.SupportedMaturities()
+}
+
+// Fetch fetches the Euribor rate from ECB API (monthly data)
+func (s *ecbSource) Fetch(ctx context.Context, maturity string) (*source.Rate, error) {
+	obs, err := s.client.FetchLatest(ctx, maturity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source.Rate{
+		Rate:            obs.Value,
+		PublicationDate: obs.Date,
+		Frequency:       source.Monthly,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/breaker"
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/config"
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+func TestFailedReloadKeepsBreakerPolicy(t *testing.T) {
+	breakersMu.Lock()
+	delete(breakers, sourceECB)
+	breakersMu.Unlock()
+	t.Cleanup(func() {
+		breakersMu.Lock()
+		delete(breakers, sourceECB)
+		breakersMu.Unlock()
+	})
+
+	b := sourceBreaker(sourceECB, breaker.Policy{FailureThreshold: 1, CoolDown: time.Hour})
+
+	// 1W is not published by the ECB, so the configuration fails checkMaturities
+	path := filepath.Join(t.TempDir(), "config.yml")
+	config := `
+maturities: ["1W"]
+sources:
+  daily:
+    enabled: false
+  ecb:
+    enabled: true
+circuit_breaker:
+  failure_threshold: 5
+  cool_down: 1m
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadAndBuild(path); err == nil {
+		t.Fatal("loadAndBuild() succeeded, want a maturity error")
+	}
+
+	b.Failure()
+	if got := b.State(); got != breaker.Open {
+		t.Errorf("State() after one failure = %s, want %s: the failed reload changed the policy", got, breaker.Open)
+	}
+}
+
+func TestBuildSourcesCustom(t *testing.T) {
+	var settings source.Settings
+	source.RegisterFactory("internal", func(s source.Settings) (source.RateSource, error) {
+		settings = s
+		return &fakeSource{name: "internal"}, nil
+	})
+
+	cfg := config.Default()
+	cfg.Sources.Daily.Enabled = false
+	cfg.Sources.ECB.Enabled = false
+	cfg.Sources.Custom = map[string]config.Source{
+		"internal": {
+			Enabled:  true,
+			Interval: 15 * time.Minute,
+			Timeout:  5 * time.Second,
+			Schedule: config.ScheduleInterval,
+			URL:      "https://rates.example.com",
+			Options:  map[string]string{"desk": "treasury"},
+		},
+	}
+
+	sources, err := buildSources(cfg)
+	if err != nil {
+		t.Fatalf("buildSources() unexpected error: %v", err)
+	}
+	if names := sources.Names(); len(names) != 1 || names[0] != "internal" {
+		t.Errorf("Names() = %v, want [internal]", names)
+	}
+	if settings.URL != "https://rates.example.com" || settings.Options["desk"] != "treasury" || settings.Client == nil {
+		t.Errorf("factory settings = %+v, want the configured URL, options and a client", settings)
+	}
+
+	opts := exporterConfigFromConfig(cfg, sources).options["internal"]
+	if opts.interval != 15*time.Minute || opts.timeout != 5*time.Second || opts.schedule != nil {
+		t.Errorf("options = %+v, want the configured interval and timeout", opts)
+	}
+
+	cfg.Sources.Custom["unknown"] = config.Source{Enabled: true}
+	if _, err := buildSources(cfg); err == nil {
+		t.Error("buildSources() with an unregistered source: expected error, got nil")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestScraperSourceSharesPage(t *testing.T) {
+	// The three latest business days, as the page shows them
+	var rows strings.Builder
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	for n := 0; n < 3; day = day.AddDate(0, 0, -1) {
+		if calendar.IsBusinessDay(day) {
+			fmt.Fprintf(&rows, "<tr><td>%s</td><td>2.0%d %%</td></tr>", day.Format("01/02/2006"), n)
+			n++
+		}
+	}
+	page := `<html lang="en"><body><h1>Euribor 3 months</h1><table class="table_historiek">
+<thead><tr><th>Date</th><th>Rate</th></tr></thead><tbody>` + rows.String() + `</tbody></table></body></html>`
+
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page)), Request: req}, nil
+	})}
+	src := newScraperSource(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := src.Fetch(ctx, "3M"); err != nil {
+		t.Fatalf("Fetch() unexpected error: %v", err)
+	}
+	history, err := src.FetchHistory(ctx, "3M")
+	if err != nil {
+		t.Fatalf("FetchHistory() unexpected error: %v", err)
+	}
+	if len(history) != 3 {
+		t.Errorf("FetchHistory() returned %d rates, want 3", len(history))
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("page downloaded %d times during one fetch, want 1", got)
+	}
+	cancel()
+
+	// A later caller must not get the history of a finished fetch
+	if _, err := src.FetchHistory(context.Background(), "3M"); err != nil {
+		t.Fatalf("FetchHistory() unexpected error: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("page downloaded %d times after a new FetchHistory, want 2", got)
+	}
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// version is the file format written by Save
+const version = 1
+
+// Series is the last accepted rate of one source and maturity
+type Series struct {
+	Source          string    `json:"source"`
+	Maturity        string    `json:"maturity"`
+	Rate            float64   `json:"rate"`
+	PublicationDate time.Time `json:"publication_date"`
+	Frequency       string    `json:"frequency,omitempty"`
+	LastSuccess     time.Time `json:"last_success"`
+}
+
+// Observation is an accepted rate kept as fetch history
+type Observation struct {
+	Maturity        string    `json:"maturity"`
+	Frequency       string    `json:"frequency"`
+	PublicationDate time.Time `json:"publication_date"`
+	Rate            float64   `json:"rate"`
+}
+
+// State is everything the exporter keeps across restarts
+type State struct {
+	Version      int           `json:"version"`
+	SavedAt      time.Time     `json:"saved_at"`
+	Series       []Series      `json:"series"`
+	Observations []Observation `json:"observations"`
+}
+
+// Load reads the state saved at path. A missing file yields an empty state.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{Version: version}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if s.Version != version {
+		return nil, fmt.Errorf("%s has unsupported version %d, want %d", path, s.Version, version)
+	}
+
+	return &s, nil
+}
+
+// Save writes s to path. The file is replaced atomically, so a crash while
+// saving leaves the previous state intact.
+func Save(path string, s *State) error {
+	s.Version = version
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
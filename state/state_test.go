@@ -0,0 +1,79 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	empty, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() of missing file unexpected error: %v", err)
+	}
+	if len(empty.Series) != 0 || len(empty.Observations) != 0 {
+		t.Errorf("Load() of missing file = %+v, want empty state", empty)
+	}
+
+	pubDate := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+	want := &State{
+		SavedAt: time.Date(2025, 12, 15, 12, 0, 0, 0, time.UTC),
+		Series: []Series{{
+			Source:          "daily-scraper",
+			Maturity:        "3M",
+			Rate:            2.081,
+			PublicationDate: pubDate,
+			Frequency:       "daily",
+			LastSuccess:     time.Date(2025, 12, 15, 11, 5, 0, 0, time.UTC),
+		}},
+		Observations: []Observation{{
+			Maturity:        "3M",
+			Frequency:       "daily",
+			PublicationDate: pubDate,
+			Rate:            2.081,
+		}},
+	}
+
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save() unexpected error: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(got.Series) != 1 || got.Series[0] != want.Series[0] {
+		t.Errorf("Load() series = %+v, want %+v", got.Series, want.Series)
+	}
+	if len(got.Observations) != 1 || got.Observations[0] != want.Observations[0] {
+		t.Errorf("Load() observations = %+v, want %+v", got.Observations, want.Observations)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries after Save(), want 1", len(entries))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"corrupt.json": `{"version": 1, "series": [`,
+		"future.json":  `{"version": 99}`,
+	}
+
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%s) expected error, got nil", name)
+		}
+	}
+}
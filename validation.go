@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/calendar"
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+// Validation rules reported in euribor_validation_rejections_total
+const (
+	ruleBounds     = "bounds"
+	ruleJump       = "jump"
+	ruleDivergence = "divergence"
+)
+
+// validationPolicy bounds the rates accepted from sources
+type validationPolicy struct {
+	minRate, maxRate float64 // Plausible range in percent; not checked unless maxRate > minRate
+	maxJumpBP        float64 // Largest change over one business day in basis points; 0 disables
+	maxDivergenceBP  float64 // Largest difference to other sources in basis points; 0 disables
+}
+
+// rejection explains why a fetched rate was not accepted
+type rejection struct {
+	rule   string
+	detail string
+}
+
+func (r *rejection) Error() string {
+	return fmt.Sprintf("%s: %s", r.rule, r.detail)
+}
+
+// validate checks rate against the previous accepted rate of the same series,
+// if any, and the accepted rates of other sources for the same maturity. It
+// returns nil if rate is plausible.
+//
+// Jumps are allowed maxJumpBP times the square root of the business days
+// between the two publications. The allowance grows slowly enough to still
+// check monthly series and values after long gaps, yet without bound, so a
+// series that was rejected for a while eventually catches up with a genuine
+// move. Divergence is only checked against peers of the same frequency and
+// publication date: a daily fixing says nothing about a monthly average, and
+// a peer that was wrongly accepted can only block a single publication. The
+// built-in sources never share a frequency, so it only applies to custom ones.
+func (p validationPolicy) validate(rate source.Rate, previous *source.Rate, peers map[string]source.Rate) *rejection {
+	if p.maxRate > p.minRate && (rate.Rate < p.minRate || rate.Rate > p.maxRate) {
+		return &rejection{ruleBounds, fmt.Sprintf("%.3f%% is outside [%g%%, %g%%]", rate.Rate, p.minRate, p.maxRate)}
+	}
+
+	if p.maxJumpBP > 0 && previous != nil {
+		days := max(1, calendar.BusinessDaysBetween(previous.PublicationDate, rate.PublicationDate))
+		jump := basisPoints(rate.Rate - previous.Rate)
+		if allowed := p.maxJumpBP * math.Sqrt(float64(days)); math.Abs(jump) > allowed {
+			return &rejection{ruleJump, fmt.Sprintf("moved %+.1fbp from %.3f%% over %d business day(s), allowed %.1fbp",
+				jump, previous.Rate, days, allowed)}
+		}
+	}
+
+	if p.maxDivergenceBP > 0 {
+		names := make([]string, 0, len(peers))
+		for name, peer := range peers {
+			if peer.Frequency == rate.Frequency && sameDay(peer.PublicationDate, rate.PublicationDate) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			diff := basisPoints(rate.Rate - peers[name].Rate)
+			if math.Abs(diff) > p.maxDivergenceBP {
+				return &rejection{ruleDivergence, fmt.Sprintf("%+.1fbp from %s (%.3f%%), allowed %gbp",
+					diff, name, peers[name].Rate, p.maxDivergenceBP)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameDay reports whether a and b fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+func basisPoints(percent float64) float64 {
+	return percent * 100
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoGstickGo/euribor-exporter/source"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	policy := validationPolicy{minRate: -2, maxRate: 10, maxJumpBP: 50, maxDivergenceBP: 100}
+
+	monday := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+	rate := func(r float64, date time.Time) source.Rate {
+		return source.Rate{Rate: r, PublicationDate: date, Frequency: source.Daily}
+	}
+	monthly := source.Rate{Rate: 3.2, PublicationDate: monday, Frequency: source.Monthly}
+	previous := rate(2.081, time.Date(2025, 12, 12, 0, 0, 0, 0, time.UTC)) // Friday
+
+	tests := []struct {
+		name     string
+		policy   validationPolicy
+		rate     source.Rate
+		previous *source.Rate
+		peers    map[string]source.Rate
+		want     string
+	}{
+		{"plausible", policy, rate(2.1, monday), &previous, map[string]source.Rate{"ecb": rate(2.0, monday)}, ""},
+		{"first value", policy, rate(2.1, monday), nil, nil, ""},
+		{"above bounds", policy, rate(21.0, monday), nil, nil, ruleBounds},
+		{"below bounds", policy, rate(-2.5, monday), nil, nil, ruleBounds},
+		{"zero policy accepts anything", validationPolicy{}, rate(21.0, monday), &previous, nil, ""},
+		{"jump over one business day", policy, rate(2.7, monday), &previous, nil, ruleJump},
+		{"jump spread over business days", policy, rate(2.7, monday.AddDate(0, 0, 2)), &previous, nil, ""},
+		{"jump over a month", policy, rate(5.2, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)), &previous, nil, ruleJump},
+		{"long gap catches up", policy, rate(5.2, time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC)), &previous, nil, ""},
+		{"diverges from peer", policy, rate(2.1, monday), nil, map[string]source.Rate{"ecb": rate(3.2, monday)}, ruleDivergence},
+		{"peer of other frequency", policy, rate(2.1, monday), nil, map[string]source.Rate{"ecb": monthly}, ""},
+		{"peer of other publication", policy, rate(2.1, monday), nil, map[string]source.Rate{"ecb": rate(3.2, monday.AddDate(0, 0, -3))}, ""},
+		{"divergence disabled", validationPolicy{maxDivergenceBP: 0}, rate(2.1, monday), nil, map[string]source.Rate{"ecb": rate(3.2, monday)}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if r := tt.policy.validate(tt.rate, tt.previous, tt.peers); r != nil {
+				got = r.rule
+			}
+			if got != tt.want {
+				t.Errorf("validate() rule = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}